@@ -0,0 +1,145 @@
+package apperror
+
+import (
+	"errors"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindNotFound
+	KindValidation
+	KindConflict
+	KindUnauthorized
+	KindForbidden
+	KindTimeout
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindNotFound:
+		return "not_found"
+	case KindValidation:
+		return "validation"
+	case KindConflict:
+		return "conflict"
+	case KindUnauthorized:
+		return "unauthorized"
+	case KindForbidden:
+		return "forbidden"
+	case KindTimeout:
+		return "timeout"
+	default:
+		return "internal"
+	}
+}
+
+// Error is a domain error that carries the kind of failure up to the HTTP
+// layer. Fields holds per-field messages for validation failures.
+type Error struct {
+	Kind    Kind
+	Message string
+	Fields  map[string]string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil && e.Message == "" {
+		return e.Err.Error()
+	}
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func New(kind Kind, message string) error {
+	return &Error{Kind: kind, Message: message}
+}
+
+func Wrap(kind Kind, err error, message string) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Kind: kind, Message: message, Err: err}
+}
+
+func NotFound(message string) error {
+	return New(KindNotFound, message)
+}
+
+func Conflict(message string) error {
+	return New(KindConflict, message)
+}
+
+func Unauthorized(message string) error {
+	return New(KindUnauthorized, message)
+}
+
+func Forbidden(message string) error {
+	return New(KindForbidden, message)
+}
+
+func Validation(message string, fields map[string]string) error {
+	return &Error{Kind: KindValidation, Message: message, Fields: fields}
+}
+
+func InvalidField(field, message string) error {
+	return Validation("invalid "+field, map[string]string{field: message})
+}
+
+// FromValidation converts the errors returned by ozzo-validation into a
+// validation Error whose fields are flattened to dotted paths such as
+// "items.0.quantity". Internal validation errors are kept as internal.
+func FromValidation(err error) error {
+	if err == nil {
+		return nil
+	}
+	var internal validation.InternalError
+	if errors.As(err, &internal) {
+		return Wrap(KindInternal, err, "")
+	}
+	var errs validation.Errors
+	if !errors.As(err, &errs) {
+		return Wrap(KindValidation, err, "validation failed")
+	}
+	fields := map[string]string{}
+	flatten(fields, "", errs)
+	return Validation("validation failed", fields)
+}
+
+func flatten(fields map[string]string, prefix string, errs validation.Errors) {
+	for k, err := range errs {
+		name := k
+		if prefix != "" {
+			name = prefix + "." + k
+		}
+		var nested validation.Errors
+		if errors.As(err, &nested) {
+			flatten(fields, name, nested)
+			continue
+		}
+		fields[name] = err.Error()
+	}
+}
+
+// KindOf reports the kind of the first Error in err's chain, or KindInternal
+// when there is none.
+func KindOf(err error) Kind {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	return KindInternal
+}
+
+func Is(err error, kind Kind) bool {
+	return err != nil && KindOf(err) == kind
+}
@@ -0,0 +1,53 @@
+package controller
+
+import (
+	"net/http"
+	"next-learn-go/apperror"
+	"next-learn-go/entity"
+	"next-learn-go/usecase"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type CreditNoteController interface {
+	GetCreditNotes(c echo.Context) error
+	CreateCreditNote(c echo.Context) error
+}
+
+type creditNoteController struct {
+	cu usecase.CreditNoteUseCase
+}
+
+func NewCreditNoteController(cu usecase.CreditNoteUseCase) CreditNoteController {
+	return &creditNoteController{cu}
+}
+
+func (cc *creditNoteController) GetCreditNotes(c echo.Context) error {
+	invoiceId, err := uuid.Parse(c.Param("invoiceId"))
+	if err != nil {
+		return apperror.InvalidField("invoiceId", "must be a valid UUID")
+	}
+	creditNotesRes, err := cc.cu.GetCreditNotes(c.Request().Context(), invoiceId)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, creditNotesRes)
+}
+
+func (cc *creditNoteController) CreateCreditNote(c echo.Context) error {
+	invoiceId, err := uuid.Parse(c.Param("invoiceId"))
+	if err != nil {
+		return apperror.InvalidField("invoiceId", "must be a valid UUID")
+	}
+
+	creditNote := entity.CreditNote{}
+	if err := c.Bind(&creditNote); err != nil {
+		return err
+	}
+	creditNoteRes, err := cc.cu.CreateCreditNote(c.Request().Context(), creditNote, invoiceId)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusCreated, creditNoteRes)
+}
@@ -2,8 +2,11 @@ package controller
 
 import (
 	"net/http"
+	"next-learn-go/apperror"
+	"next-learn-go/entity"
 	"next-learn-go/usecase"
 
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 )
 
@@ -11,6 +14,10 @@ type CustomerController interface {
 	GetAllCustomers(c echo.Context) error
 	GetFilteredCustomers(c echo.Context) error
 	GetCustomerCount(c echo.Context) error
+	GetCustomerById(c echo.Context) error
+	CreateCustomer(c echo.Context) error
+	UpdateCustomer(c echo.Context) error
+	DeleteCustomer(c echo.Context) error
 }
 
 type customerController struct {
@@ -22,26 +29,80 @@ func NewCustomerController(cu usecase.CustomerUseCase) CustomerController {
 }
 
 func (cc *customerController) GetAllCustomers(c echo.Context) error {
-	customers, err := cc.cu.GetAllCustomers()
+	customers, err := cc.cu.GetAllCustomers(c.Request().Context())
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, err.Error())
+		return err
 	}
 	return c.JSON(http.StatusOK, customers)
 }
 
 func (cc *customerController) GetFilteredCustomers(c echo.Context) error {
 	query := c.QueryParams().Get("query")
-	customers, err := cc.cu.GetFilteredCustomers(query)
+	customers, err := cc.cu.GetFilteredCustomers(c.Request().Context(), query)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, err.Error())
+		return err
 	}
 	return c.JSON(http.StatusOK, customers)
 }
 
 func (cc *customerController) GetCustomerCount(c echo.Context) error {
-	count, err := cc.cu.GetCustomerCount()
+	count, err := cc.cu.GetCustomerCount(c.Request().Context())
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, err.Error())
+		return err
 	}
 	return c.JSON(http.StatusOK, count)
 }
+
+func (cc *customerController) GetCustomerById(c echo.Context) error {
+	customerId, err := uuid.Parse(c.Param("customerId"))
+	if err != nil {
+		return apperror.InvalidField("customerId", "must be a valid UUID")
+	}
+	customerRes, err := cc.cu.GetCustomerById(c.Request().Context(), customerId)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, customerRes)
+}
+
+func (cc *customerController) CreateCustomer(c echo.Context) error {
+	customer := entity.Customer{}
+	if err := c.Bind(&customer); err != nil {
+		return err
+	}
+	customerRes, err := cc.cu.CreateCustomer(c.Request().Context(), customer)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusCreated, customerRes)
+}
+
+func (cc *customerController) UpdateCustomer(c echo.Context) error {
+	customerId, err := uuid.Parse(c.Param("customerId"))
+	if err != nil {
+		return apperror.InvalidField("customerId", "must be a valid UUID")
+	}
+
+	customer := entity.Customer{}
+	if err := c.Bind(&customer); err != nil {
+		return err
+	}
+	customerRes, err := cc.cu.UpdateCustomer(c.Request().Context(), customer, customerId)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, customerRes)
+}
+
+func (cc *customerController) DeleteCustomer(c echo.Context) error {
+	customerId, err := uuid.Parse(c.Param("customerId"))
+	if err != nil {
+		return apperror.InvalidField("customerId", "must be a valid UUID")
+	}
+
+	err = cc.cu.DeleteCustomer(c.Request().Context(), customerId)
+	if err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,63 @@
+package controller
+
+import (
+	"io/fs"
+	"net/http"
+	"next-learn-go/openapi"
+
+	"github.com/labstack/echo/v4"
+	swaggerFiles "github.com/swaggo/files/v2"
+)
+
+type DocsController interface {
+	OpenAPI(c echo.Context) error
+	SwaggerUI(c echo.Context) error
+	SwaggerUIAsset(c echo.Context) error
+}
+
+type docsController struct {
+	assets http.Handler
+}
+
+func NewDocsController() DocsController {
+	return &docsController{http.StripPrefix("/docs/", http.FileServer(http.FS(swaggerFiles.FS)))}
+}
+
+// Swagger UI の初期化スクリプトを差し替え、この API のドキュメントを読み込ませる
+const swaggerInitializer = `window.onload = function() {
+  window.ui = SwaggerUIBundle({
+    url: "/openapi.json",
+    dom_id: "#swagger-ui",
+    deepLinking: true,
+    persistAuthorization: true,
+    presets: [SwaggerUIBundle.presets.apis, SwaggerUIStandalonePreset],
+    plugins: [SwaggerUIBundle.plugins.DownloadUrl],
+    layout: "StandaloneLayout"
+  });
+};
+`
+
+func (dc *docsController) OpenAPI(c echo.Context) error {
+	return c.Blob(http.StatusOK, echo.MIMEApplicationJSON, openapi.Spec())
+}
+
+// SwaggerUI redirects to /docs/ so that the relative asset paths in the UI's
+// index.html resolve under /docs.
+func (dc *docsController) SwaggerUI(c echo.Context) error {
+	return c.Redirect(http.StatusMovedPermanently, "/docs/")
+}
+
+func (dc *docsController) SwaggerUIAsset(c echo.Context) error {
+	switch c.Param("*") {
+	case "", "index.html":
+		index, err := fs.ReadFile(swaggerFiles.FS, "index.html")
+		if err != nil {
+			return err
+		}
+		return c.HTMLBlob(http.StatusOK, index)
+	case "swagger-initializer.js":
+		return c.Blob(http.StatusOK, "application/javascript; charset=utf-8", []byte(swaggerInitializer))
+	}
+	dc.assets.ServeHTTP(c.Response(), c.Request())
+	return nil
+}
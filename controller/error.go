@@ -0,0 +1,93 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"next-learn-go/apperror"
+	"next-learn-go/logger"
+
+	"github.com/labstack/echo/v4"
+)
+
+const problemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details body.
+type Problem struct {
+	Type     string            `json:"type"`
+	Title    string            `json:"title"`
+	Status   int               `json:"status"`
+	Detail   string            `json:"detail,omitempty"`
+	Instance string            `json:"instance,omitempty"`
+	Errors   map[string]string `json:"errors,omitempty"`
+}
+
+var kindStatus = map[apperror.Kind]int{
+	apperror.KindNotFound:     http.StatusNotFound,
+	apperror.KindValidation:   http.StatusUnprocessableEntity,
+	apperror.KindConflict:     http.StatusConflict,
+	apperror.KindUnauthorized: http.StatusUnauthorized,
+	apperror.KindForbidden:    http.StatusForbidden,
+	apperror.KindTimeout:      http.StatusServiceUnavailable,
+}
+
+func HTTPErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	problem := newProblem(err)
+	problem.Instance = c.Request().URL.Path
+	if problem.Status >= http.StatusInternalServerError {
+		logger.FromContext(c.Request().Context()).Error("request failed", "error", err)
+	}
+
+	if c.Request().Method == http.MethodHead {
+		err = c.NoContent(problem.Status)
+	} else {
+		var body []byte
+		body, err = json.Marshal(problem)
+		if err == nil {
+			err = c.Blob(problem.Status, problemContentType, body)
+		}
+	}
+	if err != nil {
+		logger.FromContext(c.Request().Context()).Error("writing error response failed", "error", err)
+	}
+}
+
+func newProblem(err error) Problem {
+	var appErr *apperror.Error
+	if errors.As(err, &appErr) {
+		status, ok := kindStatus[appErr.Kind]
+		if !ok {
+			return problemFromStatus(http.StatusInternalServerError, "")
+		}
+		problem := problemFromStatus(status, appErr.Message)
+		problem.Errors = appErr.Fields
+		return problem
+	}
+
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		detail := ""
+		if message, ok := httpErr.Message.(string); ok {
+			detail = message
+		}
+		if httpErr.Code >= http.StatusInternalServerError {
+			detail = ""
+		}
+		return problemFromStatus(httpErr.Code, detail)
+	}
+
+	return problemFromStatus(http.StatusInternalServerError, "")
+}
+
+func problemFromStatus(status int, detail string) Problem {
+	return Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
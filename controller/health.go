@@ -0,0 +1,36 @@
+package controller
+
+import (
+	"net/http"
+	"next-learn-go/infrastructure/health"
+
+	"github.com/labstack/echo/v4"
+)
+
+type HealthController interface {
+	Live(c echo.Context) error
+	Ready(c echo.Context) error
+}
+
+type healthController struct {
+	hr health.Registry
+}
+
+func NewHealthController(hr health.Registry) HealthController {
+	return &healthController{hr}
+}
+
+func (hc *healthController) Live(c echo.Context) error {
+	return healthResponse(c, hc.hr.Live(c.Request().Context()))
+}
+
+func (hc *healthController) Ready(c echo.Context) error {
+	return healthResponse(c, hc.hr.Ready(c.Request().Context()))
+}
+
+func healthResponse(c echo.Context, report health.Report) error {
+	if report.Status != health.StatusUp {
+		return c.JSON(http.StatusServiceUnavailable, report)
+	}
+	return c.JSON(http.StatusOK, report)
+}
@@ -1,10 +1,14 @@
 package controller
 
 import (
+	"context"
+	"fmt"
 	"net/http"
+	"next-learn-go/apperror"
 	"next-learn-go/entity"
 	"next-learn-go/usecase"
 	"strconv"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
@@ -17,8 +21,12 @@ type InvoiceController interface {
 	GetInvoiceStatusCount(c echo.Context) error
 	GetInvoicesPages(c echo.Context) error
 	GetInvoiceById(c echo.Context) error
+	GetInvoiceByNumber(c echo.Context) error
+	GetInvoicePdf(c echo.Context) error
 	CreateInvoice(c echo.Context) error
 	UpdateInvoice(c echo.Context) error
+	SendInvoice(c echo.Context) error
+	VoidInvoice(c echo.Context) error
 	DeleteInvoice(c echo.Context) error
 }
 
@@ -31,57 +39,137 @@ func NewInvoiceController(iu usecase.InvoiceUseCase) InvoiceController {
 }
 
 func (ic *invoiceController) GetLatestInvoices(c echo.Context) error {
-	offset, err := strconv.Atoi(c.QueryParam("offset"))
+	limit, err := strconv.Atoi(c.QueryParam("limit"))
 	if err != nil {
-		offset = 0
+		limit = 6
 	}
 
-	limit, err := strconv.Atoi(c.QueryParam("limit"))
+	// cursor が指定された場合はキーセットページネーションで返す
+	if c.QueryParams().Has("cursor") {
+		page, err := ic.iu.GetLatestInvoicesPage(c.Request().Context(), c.QueryParam("cursor"), limit)
+		if err != nil {
+			return err
+		}
+		page.Links = pageLinks(c, page.NextCursor, page.PrevCursor)
+		return c.JSON(http.StatusOK, page)
+	}
+
+	offset, err := strconv.Atoi(c.QueryParam("offset"))
 	if err != nil {
-		limit = 6
+		offset = 0
 	}
 
-	invoiceRes, err := ic.iu.GetLatestInvoices(offset, limit)
+	invoiceRes, err := ic.iu.GetLatestInvoices(c.Request().Context(), offset, limit)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, err.Error())
+		return err
 	}
 	return c.JSON(http.StatusOK, invoiceRes)
 }
 
 func (ic *invoiceController) GetFilteredInvoices(c echo.Context) error {
-	offset, err := strconv.Atoi(c.QueryParam("offset"))
+	limit, err := strconv.Atoi(c.QueryParam("limit"))
 	if err != nil {
-		offset = 0
+		limit = 20
 	}
 
-	limit, err := strconv.Atoi(c.QueryParam("limit"))
+	filter, err := invoiceFilterFromQuery(c)
 	if err != nil {
-		limit = 20
+		return err
+	}
+
+	if c.QueryParams().Has("cursor") {
+		// キーセットは (date, id) の並びに依存するため、並べ替えを黙って無視せずに拒否する
+		if len(filter.Sort) > 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, "sort cannot be combined with cursor")
+		}
+		withTotal, _ := strconv.ParseBool(c.QueryParam("with_total"))
+		page, err := ic.iu.GetFilteredInvoicesPage(c.Request().Context(), filter, c.QueryParam("cursor"), limit, withTotal)
+		if err != nil {
+			return err
+		}
+		page.Links = pageLinks(c, page.NextCursor, page.PrevCursor)
+		return c.JSON(http.StatusOK, page)
 	}
 
-	query := c.QueryParams().Get("query")
+	offset, err := strconv.Atoi(c.QueryParam("offset"))
+	if err != nil {
+		offset = 0
+	}
 
-	invoiceRes, err := ic.iu.GetFilteredInvoices(query, offset, limit)
+	invoiceRes, err := ic.iu.GetFilteredInvoices(c.Request().Context(), filter, offset, limit)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, err.Error())
+		return err
 	}
 	return c.JSON(http.StatusOK, invoiceRes)
 }
 
+// invoiceFilterFromQuery reads the filters shared by the invoice search
+// endpoints. Values that cannot be parsed are rejected rather than ignored, so
+// a typo does not silently widen the search.
+func invoiceFilterFromQuery(c echo.Context) (entity.InvoiceFilter, error) {
+	filter := entity.InvoiceFilter{
+		Query:  c.QueryParam("query"),
+		Status: c.QueryParam("status"),
+		Sort:   parseSort(c.QueryParam("sort")),
+	}
+	if v := c.QueryParam("overdue"); v != "" {
+		overdue, err := strconv.ParseBool(v)
+		if err != nil {
+			return entity.InvoiceFilter{}, apperror.InvalidField("overdue", "must be true or false")
+		}
+		filter.Overdue = overdue
+	}
+	if v := c.QueryParam("customer_id"); v != "" {
+		customerId, err := uuid.Parse(v)
+		if err != nil {
+			return entity.InvoiceFilter{}, apperror.InvalidField("customer_id", "must be a valid UUID")
+		}
+		filter.CustomerId = customerId
+	}
+	// 複数の値が不正なときも同じフィールドを報告するよう、順番に読む
+	amounts := []struct {
+		name string
+		dst  **int
+	}{{"amount_min", &filter.AmountMin}, {"amount_max", &filter.AmountMax}}
+	for _, a := range amounts {
+		if v := c.QueryParam(a.name); v != "" {
+			amount, err := strconv.Atoi(v)
+			if err != nil {
+				return entity.InvoiceFilter{}, apperror.InvalidField(a.name, "must be an integer amount in cents")
+			}
+			*a.dst = &amount
+		}
+	}
+	dates := []struct {
+		name string
+		dst  *time.Time
+	}{{"date_from", &filter.DateFrom}, {"date_to", &filter.DateTo}}
+	for _, d := range dates {
+		if v := c.QueryParam(d.name); v != "" {
+			date, err := time.Parse(time.DateOnly, v)
+			if err != nil {
+				return entity.InvoiceFilter{}, apperror.InvalidField(d.name, "must be a date in YYYY-MM-DD format")
+			}
+			*d.dst = date
+		}
+	}
+	return filter, nil
+}
+
 func (ic *invoiceController) GetInvoiceCount(c echo.Context) error {
-	invoiceRes, err := ic.iu.GetInvoiceCount()
+	invoiceRes, err := ic.iu.GetInvoiceCount(c.Request().Context())
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, err.Error())
+		return err
 	}
 	return c.JSON(http.StatusOK, invoiceRes)
 }
 
 func (ic *invoiceController) GetInvoiceStatusCount(c echo.Context) error {
-	pending, paid, err := ic.iu.GetInvoiceStatusCount()
+	invoiceRes, err := ic.iu.GetInvoiceStatusCount(c.Request().Context())
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, err.Error())
+		return err
 	}
-	return c.JSON(http.StatusOK, map[string]int{"pending": pending, "paid": paid})
+	return c.JSON(http.StatusOK, invoiceRes)
 }
 
 func (ic *invoiceController) GetInvoicesPages(c echo.Context) error {
@@ -95,11 +183,14 @@ func (ic *invoiceController) GetInvoicesPages(c echo.Context) error {
 		limit = 20
 	}
 
-	query := c.QueryParams().Get("query")
+	filter, err := invoiceFilterFromQuery(c)
+	if err != nil {
+		return err
+	}
 
-	invoiceRes, err := ic.iu.GetInvoicesPages(query, offset, limit)
+	invoiceRes, err := ic.iu.GetInvoicesPages(c.Request().Context(), filter, offset, limit)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, err.Error())
+		return err
 	}
 	return c.JSON(http.StatusOK, invoiceRes)
 }
@@ -107,25 +198,46 @@ func (ic *invoiceController) GetInvoicesPages(c echo.Context) error {
 func (ic *invoiceController) GetInvoiceById(c echo.Context) error {
 	invoiceId, err := uuid.Parse(c.Param("invoiceId"))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, err.Error())
+		return apperror.InvalidField("invoiceId", "must be a valid UUID")
 	}
-	invoiceRes, err := ic.iu.GetInvoiceById(invoiceId)
+	invoiceRes, err := ic.iu.GetInvoiceById(c.Request().Context(), invoiceId)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, invoiceRes)
+}
+
+func (ic *invoiceController) GetInvoiceByNumber(c echo.Context) error {
+	invoiceRes, err := ic.iu.GetInvoiceByNumber(c.Request().Context(), c.Param("invoiceNumber"))
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, err.Error())
+		return err
 	}
 	return c.JSON(http.StatusOK, invoiceRes)
 }
 
+func (ic *invoiceController) GetInvoicePdf(c echo.Context) error {
+	invoiceId, err := uuid.Parse(c.Param("invoiceId"))
+	if err != nil {
+		return apperror.InvalidField("invoiceId", "must be a valid UUID")
+	}
+	document, err := ic.iu.GetInvoicePdf(c.Request().Context(), invoiceId)
+	if err != nil {
+		return err
+	}
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("inline; filename=\"invoice-%s.pdf\"", invoiceId))
+	return c.Blob(http.StatusOK, "application/pdf", document)
+}
+
 func (ic *invoiceController) CreateInvoice(c echo.Context) error {
 
 	invoice := entity.Invoice{}
 	if err := c.Bind(&invoice); err != nil {
-		return c.JSON(http.StatusBadRequest, err.Error())
+		return err
 	}
 
-	invoiceRes, err := ic.iu.CreateInvoice(invoice)
+	invoiceRes, err := ic.iu.CreateInvoice(c.Request().Context(), invoice)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, err.Error())
+		return err
 	}
 	return c.JSON(http.StatusCreated, invoiceRes)
 }
@@ -133,16 +245,36 @@ func (ic *invoiceController) CreateInvoice(c echo.Context) error {
 func (ic *invoiceController) UpdateInvoice(c echo.Context) error {
 	invoiceId, err := uuid.Parse(c.Param("invoiceId"))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, err.Error())
+		return apperror.InvalidField("invoiceId", "must be a valid UUID")
 	}
 
 	invoice := entity.Invoice{}
 	if err := c.Bind(&invoice); err != nil {
-		return c.JSON(http.StatusBadRequest, err.Error())
+		return err
+	}
+	invoiceRes, err := ic.iu.UpdateInvoice(c.Request().Context(), invoice, invoiceId)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, invoiceRes)
+}
+
+func (ic *invoiceController) SendInvoice(c echo.Context) error {
+	return ic.transitionInvoice(c, ic.iu.SendInvoice)
+}
+
+func (ic *invoiceController) VoidInvoice(c echo.Context) error {
+	return ic.transitionInvoice(c, ic.iu.VoidInvoice)
+}
+
+func (ic *invoiceController) transitionInvoice(c echo.Context, transition func(ctx context.Context, invoiceId uuid.UUID) (entity.GetInvoiceByIdResponse, error)) error {
+	invoiceId, err := uuid.Parse(c.Param("invoiceId"))
+	if err != nil {
+		return apperror.InvalidField("invoiceId", "must be a valid UUID")
 	}
-	invoiceRes, err := ic.iu.UpdateInvoice(invoice, invoiceId)
+	invoiceRes, err := transition(c.Request().Context(), invoiceId)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, err.Error())
+		return err
 	}
 	return c.JSON(http.StatusOK, invoiceRes)
 }
@@ -150,12 +282,12 @@ func (ic *invoiceController) UpdateInvoice(c echo.Context) error {
 func (ic *invoiceController) DeleteInvoice(c echo.Context) error {
 	invoiceId, err := uuid.Parse(c.Param("invoiceId"))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, err.Error())
+		return apperror.InvalidField("invoiceId", "must be a valid UUID")
 	}
 
-	err = ic.iu.DeleteInvoice(invoiceId)
+	err = ic.iu.DeleteInvoice(c.Request().Context(), invoiceId)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, err.Error())
+		return err
 	}
 	return c.NoContent(http.StatusNoContent)
 }
@@ -0,0 +1,36 @@
+package controller
+
+import (
+	"crypto/subtle"
+	"next-learn-go/apperror"
+	"next-learn-go/infrastructure/metrics"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+type MetricsController interface {
+	Metrics(c echo.Context) error
+}
+
+type metricsController struct {
+	m     metrics.Metrics
+	token string
+}
+
+// NewMetricsController serves the metrics to anyone when token is empty, and
+// otherwise only to requests with "Authorization: Bearer <token>".
+func NewMetricsController(m metrics.Metrics, token string) MetricsController {
+	return &metricsController{m, token}
+}
+
+func (mc *metricsController) Metrics(c echo.Context) error {
+	if mc.token != "" {
+		token, ok := strings.CutPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(mc.token)) != 1 {
+			return apperror.Unauthorized("invalid metrics token")
+		}
+	}
+	mc.m.Handler().ServeHTTP(c.Response(), c.Request())
+	return nil
+}
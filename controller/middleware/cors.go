@@ -12,7 +12,8 @@ func CorsMiddleware() echo.MiddlewareFunc {
 	config := middleware.CORSWithConfig(middleware.CORSConfig{
 		AllowOrigins: []string{"http://localhost:3000", os.Getenv("FE_URL")},
 		AllowHeaders: []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept,
-			echo.HeaderAccessControlAllowHeaders},
+			echo.HeaderAccessControlAllowHeaders, echo.HeaderXRequestID, "traceparent", "tracestate"},
+		ExposeHeaders:    []string{echo.HeaderXRequestID},
 		AllowMethods:     []string{"GET", "PATCH", "POST", "DELETE"},
 		AllowCredentials: true,
 	})
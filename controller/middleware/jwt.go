@@ -1,16 +1,39 @@
 package middleware
 
 import (
+	"next-learn-go/logger"
+	"next-learn-go/tenant"
+	"next-learn-go/usecase"
 	"os"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
 
 	echojwt "github.com/labstack/echo-jwt/v4"
 )
 
-func JwtMiddleware() echo.MiddlewareFunc {
+func JwtMiddleware(uu usecase.UserUseCase) echo.MiddlewareFunc {
 	jwtMiddleware := echojwt.WithConfig(echojwt.Config{
 		SigningKey: []byte(os.Getenv("SECRET")),
+		NewClaimsFunc: func(c echo.Context) jwt.Claims {
+			return new(usecase.JwtCustomClaims)
+		},
 	})
-	return jwtMiddleware
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return jwtMiddleware(func(c echo.Context) error {
+			// ログアウトやトークン再利用で失効したセッションのアクセストークンを拒否し、
+			// 権限はトークン発行時ではなく現在の役割で判定する
+			claims := c.Get("user").(*jwt.Token).Claims.(*usecase.JwtCustomClaims)
+			role, err := uu.GetSessionRole(c.Request().Context(), claims.SessionId, claims.OrganizationId, claims.UserId)
+			if err != nil {
+				return err
+			}
+			claims.Role = role
+			// 以降のクエリはトークンの組織に限定し、ログにも利用者を付ける
+			ctx := tenant.WithOrganization(c.Request().Context(), claims.OrganizationId)
+			l := logger.FromContext(ctx).With("user_id", claims.UserId, "organization_id", claims.OrganizationId)
+			c.SetRequest(c.Request().WithContext(logger.WithContext(ctx, l)))
+			return next(c)
+		})
+	}
 }
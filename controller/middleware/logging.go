@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"log/slog"
+	"next-learn-go/logger"
+	"regexp"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// 受け取ったリクエスト ID はログにそのまま出すため、安全な文字だけを許可する
+var validRequestId = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestIdMiddleware reuses the caller's X-Request-ID or generates one,
+// echoes it in the response and puts a logger tagged with it, and with the
+// trace ID when the request is traced, into the request context.
+func RequestIdMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			requestId := c.Request().Header.Get(echo.HeaderXRequestID)
+			if !validRequestId.MatchString(requestId) {
+				requestId = uuid.NewString()
+			}
+			c.Set("request_id", requestId)
+			c.Response().Header().Set(echo.HeaderXRequestID, requestId)
+
+			l := logger.FromContext(c.Request().Context()).With("request_id", requestId)
+			if sc := trace.SpanContextFromContext(c.Request().Context()); sc.IsValid() {
+				l = l.With("trace_id", sc.TraceID().String())
+			}
+			c.SetRequest(c.Request().WithContext(logger.WithContext(c.Request().Context(), l)))
+			return next(c)
+		}
+	}
+}
+
+// AccessLogMiddleware writes one log line per request with the logger from
+// the request context, which carries the request ID and, once JwtMiddleware
+// has run, the user and organization IDs.
+func AccessLogMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+			if err := next(c); err != nil {
+				// ステータスコードを確定させるため、ここでエラーレスポンスを書き込む
+				c.Error(err)
+			}
+
+			req := c.Request()
+			res := c.Response()
+			attrs := []any{
+				"method", req.Method,
+				"route", c.Path(),
+				"path", req.URL.Path,
+				"status", res.Status,
+				"latency_ms", float64(time.Since(start).Microseconds()) / 1000,
+				"bytes_in", req.ContentLength,
+				"bytes_out", res.Size,
+				"remote_ip", c.RealIP(),
+				"user_agent", req.UserAgent(),
+			}
+
+			level := slog.LevelInfo
+			if res.Status >= 500 {
+				level = slog.LevelError
+			}
+			logger.FromContext(req.Context()).Log(req.Context(), level, "request", attrs...)
+			return nil
+		}
+	}
+}
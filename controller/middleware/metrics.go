@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"next-learn-go/infrastructure/metrics"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// MetricsMiddleware records the duration of every request labelled with the
+// route template, so that /invoices/:invoiceId is one series however many
+// invoices are requested. It must run outside AccessLogMiddleware, which
+// writes error responses and so settles the status code.
+func MetricsMiddleware(m metrics.Metrics) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+			err := next(c)
+
+			route := c.Path()
+			if route == "" {
+				// 存在しないパスごとに系列が増えないようにまとめる
+				route = "unmatched"
+			}
+			m.ObserveRequest(c.Request().Method, route, c.Response().Status, time.Since(start))
+			return err
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"next-learn-go/apperror"
+	"next-learn-go/entity"
+	"next-learn-go/usecase"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+)
+
+// RequirePermission rejects the request unless the caller's role grants
+// permission. It must run after JwtMiddleware, which replaces the role in the
+// access token with the caller's current one.
+func RequirePermission(permission entity.Permission) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			token, ok := c.Get("user").(*jwt.Token)
+			if !ok {
+				return apperror.Unauthorized("missing access token")
+			}
+			claims, ok := token.Claims.(*usecase.JwtCustomClaims)
+			if !ok || !entity.HasPermission(claims.Role, permission) {
+				return apperror.Forbidden("you do not have permission to perform this action")
+			}
+			return next(c)
+		}
+	}
+}
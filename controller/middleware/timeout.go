@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// TimeoutConfig sets how long a request may run before its context, and with
+// it any SQL still in flight, is cancelled. Routes overrides Default for keys
+// of the form "METHOD /route/:param"; a zero duration disables the timeout.
+type TimeoutConfig struct {
+	Default time.Duration
+	Routes  map[string]time.Duration
+}
+
+// TimeoutConfigFromEnv applies REQUEST_TIMEOUT (default 10s) and
+// REQUEST_TIMEOUT_ROUTES, a comma separated list such as
+// "GET /invoices/:invoiceId/pdf=30s", on top of the given route defaults.
+// Unlike lifecycle.DurationFromEnv it accepts "0", which disables the timeout.
+func TimeoutConfigFromEnv(routes map[string]time.Duration) TimeoutConfig {
+	config := TimeoutConfig{Default: 10 * time.Second, Routes: map[string]time.Duration{}}
+	for route, d := range routes {
+		config.Routes[route] = d
+	}
+	if v := os.Getenv("REQUEST_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+			config.Default = d
+		} else {
+			slog.Warn("invalid REQUEST_TIMEOUT, using default", "value", v)
+		}
+	}
+	for _, entry := range strings.Split(os.Getenv("REQUEST_TIMEOUT_ROUTES"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		route, value, ok := strings.Cut(entry, "=")
+		d, err := time.ParseDuration(strings.TrimSpace(value))
+		if !ok || err != nil || d < 0 {
+			slog.Warn("invalid REQUEST_TIMEOUT_ROUTES entry, ignoring", "entry", entry)
+			continue
+		}
+		config.Routes[strings.Join(strings.Fields(route), " ")] = d
+	}
+	return config
+}
+
+func TimeoutMiddleware(config TimeoutConfig) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			timeout := config.Default
+			if d, ok := config.Routes[c.Request().Method+" "+c.Path()]; ok {
+				timeout = d
+			}
+			if timeout <= 0 {
+				return next(c)
+			}
+			ctx, cancel := context.WithTimeout(c.Request().Context(), timeout)
+			defer cancel()
+			c.SetRequest(c.Request().WithContext(ctx))
+			return next(c)
+		}
+	}
+}
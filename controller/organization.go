@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"net/http"
+	"next-learn-go/apperror"
+	"next-learn-go/entity"
+	"next-learn-go/usecase"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type OrganizationController interface {
+	GetMyOrganizations(c echo.Context) error
+	GetMembers(c echo.Context) error
+	AddMember(c echo.Context) error
+	UpdateMemberRole(c echo.Context) error
+}
+
+type organizationController struct {
+	ou usecase.OrganizationUseCase
+}
+
+func NewOrganizationController(ou usecase.OrganizationUseCase) OrganizationController {
+	return &organizationController{ou}
+}
+
+func (oc *organizationController) GetMyOrganizations(c echo.Context) error {
+	organizations, err := oc.ou.GetMyOrganizations(c.Request().Context(), jwtClaims(c).UserId)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, organizations)
+}
+
+func (oc *organizationController) GetMembers(c echo.Context) error {
+	members, err := oc.ou.GetMembers(c.Request().Context(), jwtClaims(c).OrganizationId)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, members)
+}
+
+func (oc *organizationController) AddMember(c echo.Context) error {
+	req := entity.AddOrganizationMemberRequest{}
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+	memberRes, err := oc.ou.AddMember(c.Request().Context(), jwtClaims(c).OrganizationId, req)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusCreated, memberRes)
+}
+
+func (oc *organizationController) UpdateMemberRole(c echo.Context) error {
+	userId, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		return apperror.InvalidField("userId", "must be a valid UUID")
+	}
+
+	req := entity.UpdateUserRoleRequest{}
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+	claims := jwtClaims(c)
+	memberRes, err := oc.ou.UpdateMemberRole(c.Request().Context(), claims.OrganizationId, claims.UserId, userId, req)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, memberRes)
+}
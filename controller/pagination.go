@@ -0,0 +1,40 @@
+package controller
+
+import (
+	"next-learn-go/entity"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// pageLinks builds the URLs of the pages around the current one by replacing
+// the cursor in the request URL, so the other query parameters are kept.
+func pageLinks(c echo.Context, next, prev string) entity.PageLinks {
+	link := func(cursor string) string {
+		if cursor == "" {
+			return ""
+		}
+		u := *c.Request().URL
+		query := u.Query()
+		query.Set("cursor", cursor)
+		u.RawQuery = query.Encode()
+		return u.RequestURI()
+	}
+	return entity.PageLinks{Next: link(next), Prev: link(prev)}
+}
+
+// parseSort reads a sort parameter such as "-amount,date", where a leading
+// "-" sorts that field in descending order.
+func parseSort(sort string) []entity.SortField {
+	fields := []entity.SortField{}
+	for _, name := range strings.Split(sort, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		field := entity.SortField{Field: strings.TrimPrefix(name, "-")}
+		field.Desc = strings.HasPrefix(name, "-")
+		fields = append(fields, field)
+	}
+	return fields
+}
@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"net/http"
+	"next-learn-go/apperror"
+	"next-learn-go/entity"
+	"next-learn-go/usecase"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type PaymentController interface {
+	GetPayments(c echo.Context) error
+	CreatePayment(c echo.Context) error
+	DeletePayment(c echo.Context) error
+	PayInvoice(c echo.Context) error
+	CreateRefund(c echo.Context) error
+}
+
+type paymentController struct {
+	pu usecase.PaymentUseCase
+}
+
+func NewPaymentController(pu usecase.PaymentUseCase) PaymentController {
+	return &paymentController{pu}
+}
+
+func (pc *paymentController) GetPayments(c echo.Context) error {
+	invoiceId, err := uuid.Parse(c.Param("invoiceId"))
+	if err != nil {
+		return apperror.InvalidField("invoiceId", "must be a valid UUID")
+	}
+	paymentRes, err := pc.pu.GetPayments(c.Request().Context(), invoiceId)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, paymentRes)
+}
+
+func (pc *paymentController) CreatePayment(c echo.Context) error {
+	invoiceId, err := uuid.Parse(c.Param("invoiceId"))
+	if err != nil {
+		return apperror.InvalidField("invoiceId", "must be a valid UUID")
+	}
+
+	payment := entity.Payment{}
+	if err := c.Bind(&payment); err != nil {
+		return err
+	}
+	paymentRes, err := pc.pu.CreatePayment(c.Request().Context(), payment, invoiceId)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusCreated, paymentRes)
+}
+
+func (pc *paymentController) DeletePayment(c echo.Context) error {
+	invoiceId, err := uuid.Parse(c.Param("invoiceId"))
+	if err != nil {
+		return apperror.InvalidField("invoiceId", "must be a valid UUID")
+	}
+	paymentId, err := uuid.Parse(c.Param("paymentId"))
+	if err != nil {
+		return apperror.InvalidField("paymentId", "must be a valid UUID")
+	}
+
+	if err := pc.pu.DeletePayment(c.Request().Context(), invoiceId, paymentId); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (pc *paymentController) PayInvoice(c echo.Context) error {
+	invoiceId, err := uuid.Parse(c.Param("invoiceId"))
+	if err != nil {
+		return apperror.InvalidField("invoiceId", "must be a valid UUID")
+	}
+	paymentRes, err := pc.pu.PayInvoice(c.Request().Context(), invoiceId)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, paymentRes)
+}
+
+func (pc *paymentController) CreateRefund(c echo.Context) error {
+	invoiceId, err := uuid.Parse(c.Param("invoiceId"))
+	if err != nil {
+		return apperror.InvalidField("invoiceId", "must be a valid UUID")
+	}
+	paymentId, err := uuid.Parse(c.Param("paymentId"))
+	if err != nil {
+		return apperror.InvalidField("paymentId", "must be a valid UUID")
+	}
+
+	refund := entity.Refund{}
+	if err := c.Bind(&refund); err != nil {
+		return err
+	}
+	refundRes, err := pc.pu.CreateRefund(c.Request().Context(), refund, invoiceId, paymentId)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusCreated, refundRes)
+}
@@ -0,0 +1,113 @@
+package controller
+
+import (
+	"net/http"
+	"next-learn-go/apperror"
+	"next-learn-go/entity"
+	"next-learn-go/usecase"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// defaultPreviewCount is how many upcoming invoices a preview lists when no
+// count is given.
+const defaultPreviewCount = 12
+
+type RecurringInvoiceController interface {
+	GetRecurringInvoices(c echo.Context) error
+	GetRecurringInvoiceById(c echo.Context) error
+	CreateRecurringInvoice(c echo.Context) error
+	UpdateRecurringInvoice(c echo.Context) error
+	DeleteRecurringInvoice(c echo.Context) error
+	PreviewRecurringInvoice(c echo.Context) error
+}
+
+type recurringInvoiceController struct {
+	ru usecase.RecurringInvoiceUseCase
+}
+
+func NewRecurringInvoiceController(ru usecase.RecurringInvoiceUseCase) RecurringInvoiceController {
+	return &recurringInvoiceController{ru}
+}
+
+func (rc *recurringInvoiceController) GetRecurringInvoices(c echo.Context) error {
+	recurringInvoicesRes, err := rc.ru.GetRecurringInvoices(c.Request().Context())
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, recurringInvoicesRes)
+}
+
+func (rc *recurringInvoiceController) GetRecurringInvoiceById(c echo.Context) error {
+	recurringInvoiceId, err := uuid.Parse(c.Param("recurringInvoiceId"))
+	if err != nil {
+		return apperror.InvalidField("recurringInvoiceId", "must be a valid UUID")
+	}
+	recurringInvoiceRes, err := rc.ru.GetRecurringInvoiceById(c.Request().Context(), recurringInvoiceId)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, recurringInvoiceRes)
+}
+
+func (rc *recurringInvoiceController) CreateRecurringInvoice(c echo.Context) error {
+	recurringInvoice := entity.RecurringInvoice{}
+	if err := c.Bind(&recurringInvoice); err != nil {
+		return err
+	}
+	recurringInvoiceRes, err := rc.ru.CreateRecurringInvoice(c.Request().Context(), recurringInvoice)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusCreated, recurringInvoiceRes)
+}
+
+func (rc *recurringInvoiceController) UpdateRecurringInvoice(c echo.Context) error {
+	recurringInvoiceId, err := uuid.Parse(c.Param("recurringInvoiceId"))
+	if err != nil {
+		return apperror.InvalidField("recurringInvoiceId", "must be a valid UUID")
+	}
+
+	recurringInvoice := entity.RecurringInvoice{}
+	if err := c.Bind(&recurringInvoice); err != nil {
+		return err
+	}
+	recurringInvoiceRes, err := rc.ru.UpdateRecurringInvoice(c.Request().Context(), recurringInvoice, recurringInvoiceId)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, recurringInvoiceRes)
+}
+
+func (rc *recurringInvoiceController) DeleteRecurringInvoice(c echo.Context) error {
+	recurringInvoiceId, err := uuid.Parse(c.Param("recurringInvoiceId"))
+	if err != nil {
+		return apperror.InvalidField("recurringInvoiceId", "must be a valid UUID")
+	}
+
+	if err := rc.ru.DeleteRecurringInvoice(c.Request().Context(), recurringInvoiceId); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (rc *recurringInvoiceController) PreviewRecurringInvoice(c echo.Context) error {
+	recurringInvoiceId, err := uuid.Parse(c.Param("recurringInvoiceId"))
+	if err != nil {
+		return apperror.InvalidField("recurringInvoiceId", "must be a valid UUID")
+	}
+	count := defaultPreviewCount
+	if v := c.QueryParam("count"); v != "" {
+		count, err = strconv.Atoi(v)
+		if err != nil {
+			return apperror.InvalidField("count", "must be an integer")
+		}
+	}
+	previewRes, err := rc.ru.PreviewRecurringInvoice(c.Request().Context(), recurringInvoiceId, count)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, previewRes)
+}
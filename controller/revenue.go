@@ -2,13 +2,17 @@ package controller
 
 import (
 	"net/http"
+	"next-learn-go/apperror"
+	"next-learn-go/entity"
 	"next-learn-go/usecase"
+	"time"
 
 	"github.com/labstack/echo/v4"
 )
 
 type RevenueController interface {
 	GetAllRevenues(c echo.Context) error
+	GetRevenueAggregate(c echo.Context) error
 }
 
 type revenueController struct {
@@ -20,9 +24,42 @@ func NewRevenueController(ru usecase.RevenueUseCase) RevenueController {
 }
 
 func (rc *revenueController) GetAllRevenues(c echo.Context) error {
-	revenues, err := rc.ru.GetAllRevenues()
+	revenues, err := rc.ru.GetAllRevenues(c.Request().Context())
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, err.Error())
+		return err
 	}
 	return c.JSON(http.StatusOK, revenues)
 }
+
+func (rc *revenueController) GetRevenueAggregate(c echo.Context) error {
+	// 既定は今日までの直近 12 か月を月ごとに集計する
+	now := time.Now().UTC()
+	query := entity.RevenueQuery{
+		From:        time.Date(now.Year(), now.Month()-11, 1, 0, 0, 0, 0, time.UTC),
+		To:          time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC),
+		Granularity: entity.GranularityMonth,
+	}
+	if v := c.QueryParam("from"); v != "" {
+		from, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return apperror.InvalidField("from", "must be a date in YYYY-MM-DD format")
+		}
+		query.From = from
+	}
+	if v := c.QueryParam("to"); v != "" {
+		to, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return apperror.InvalidField("to", "must be a date in YYYY-MM-DD format")
+		}
+		query.To = to
+	}
+	if v := c.QueryParam("granularity"); v != "" {
+		query.Granularity = v
+	}
+
+	revenueRes, err := rc.ru.GetRevenueAggregate(c.Request().Context(), query)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, revenueRes)
+}
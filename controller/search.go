@@ -0,0 +1,35 @@
+package controller
+
+import (
+	"net/http"
+	"next-learn-go/entity"
+	"next-learn-go/usecase"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+type SearchController interface {
+	Search(c echo.Context) error
+}
+
+type searchController struct {
+	su usecase.SearchUseCase
+}
+
+func NewSearchController(su usecase.SearchUseCase) SearchController {
+	return &searchController{su}
+}
+
+func (sc *searchController) Search(c echo.Context) error {
+	limit, err := strconv.Atoi(c.QueryParam("limit"))
+	if err != nil {
+		limit = 20
+	}
+
+	searchRes, err := sc.su.Search(c.Request().Context(), entity.SearchQuery{Query: c.QueryParam("q"), Limit: limit})
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, searchRes)
+}
@@ -5,13 +5,15 @@ import (
 	"next-learn-go/entity"
 	"next-learn-go/usecase"
 
-	"github.com/golang-jwt/jwt/v4"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
 )
 
 type UserController interface {
 	SignUp(c echo.Context) error
 	LogIn(c echo.Context) error
+	RefreshToken(c echo.Context) error
+	LogOut(c echo.Context) error
 	GetUserById(c echo.Context) error
 	GetUserByEmail(c echo.Context) error
 }
@@ -25,49 +27,71 @@ func NewUserController(uu usecase.UserUseCase) UserController {
 }
 
 func (uc *userController) SignUp(c echo.Context) error {
-	user := entity.User{}
-	if err := c.Bind(&user); err != nil {
-		return c.JSON(http.StatusBadRequest, err.Error())
+	req := entity.SignUpRequest{}
+	if err := c.Bind(&req); err != nil {
+		return err
 	}
-	userRes, err := uc.uu.SignUp(user)
+	userRes, err := uc.uu.SignUp(c.Request().Context(), req)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, err.Error())
+		return err
 	}
 	return c.JSON(http.StatusCreated, userRes)
 }
 
 func (uc *userController) LogIn(c echo.Context) error {
-	user := entity.User{}
-	if err := c.Bind(&user); err != nil {
-		return c.JSON(http.StatusBadRequest, err.Error())
+	req := entity.LoginRequest{}
+	if err := c.Bind(&req); err != nil {
+		return err
 	}
-	tokenString, err := uc.uu.Login(user)
+	user := entity.User{Email: req.Email, Password: req.Password}
+	tokenString, err := uc.uu.Login(c.Request().Context(), user, req.OrganizationId)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
 	return c.JSON(http.StatusOK, tokenString)
 
 }
 
+func (uc *userController) RefreshToken(c echo.Context) error {
+	req := entity.RefreshTokenRequest{}
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+	loginRes, err := uc.uu.RefreshToken(c.Request().Context(), req.RefreshToken)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, loginRes)
+}
+
+func (uc *userController) LogOut(c echo.Context) error {
+	claims := jwtClaims(c)
+	if err := uc.uu.Logout(c.Request().Context(), claims.SessionId); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
 func (uc *userController) GetUserById(c echo.Context) error {
-	user := c.Get("user").(*jwt.Token)
-	claims := user.Claims.(jwt.MapClaims)
-	userId := claims["user_id"]
-	userRes, err := uc.uu.GetUserById(uint(userId.(float64)))
+	claims := jwtClaims(c)
+	userRes, err := uc.uu.GetUserById(c.Request().Context(), claims.OrganizationId, claims.UserId)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, err.Error())
+		return err
 	}
 	return c.JSON(http.StatusOK, userRes)
 }
 
 func (uc *userController) GetUserByEmail(c echo.Context) error {
-	user := c.Get("user").(*jwt.Token)
-	claims := user.Claims.(jwt.MapClaims)
-	email := claims["email"].(string)
-	userRes, err := uc.uu.GetUserByEmail(email)
+	claims := jwtClaims(c)
+	userRes, err := uc.uu.GetUserByEmail(c.Request().Context(), claims.OrganizationId, claims.Email)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, err.Error())
+		return err
 	}
 	return c.JSON(http.StatusOK, userRes)
 }
+
+func jwtClaims(c echo.Context) *usecase.JwtCustomClaims {
+	user := c.Get("user").(*jwt.Token)
+	return user.Claims.(*usecase.JwtCustomClaims)
+}
@@ -0,0 +1,45 @@
+package entity
+
+import (
+	"context"
+	"next-learn-go/tenant"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// CreditNote reduces what is owed on an invoice without changing the invoice
+// itself, so that the original amount stays on record.
+type CreditNote struct {
+	bun.BaseModel `bun:"credit_notes,alias:cn"`
+
+	ID             uuid.UUID `json:"id" bun:"type:char(36),default:uuid(),pk"`
+	OrganizationId uuid.UUID `json:"-" bun:"type:char(36),notnull"`
+	InvoiceId      uuid.UUID `json:"invoice_id" bun:"type:char(36),notnull"`
+	Number         string    `json:"number" bun:",notnull,type:varchar(50)"`
+	Amount         int       `json:"amount" bun:",notnull"`
+	Reason         string    `json:"reason" bun:",notnull,type:varchar(255)"`
+	IssuedAt       time.Time `json:"issued_at" bun:",nullzero,notnull"`
+	CreatedAt      time.Time `json:"-" bun:",nullzero,notnull,default:current_timestamp"`
+}
+
+func (*CreditNote) BeforeSelect(ctx context.Context, q *bun.SelectQuery) error {
+	return tenant.Select(ctx, q)
+}
+
+func (cn *CreditNote) BeforeAppendModel(ctx context.Context, q bun.Query) error {
+	if _, ok := q.(*bun.InsertQuery); ok {
+		return tenant.Assign(ctx, &cn.OrganizationId)
+	}
+	return nil
+}
+
+type CreditNoteResponse struct {
+	ID        uuid.UUID `json:"id"`
+	InvoiceId uuid.UUID `json:"invoice_id"`
+	Number    string    `json:"number"`
+	Amount    int       `json:"amount"`
+	Reason    string    `json:"reason"`
+	IssuedAt  time.Time `json:"issued_at"`
+}
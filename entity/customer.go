@@ -1,6 +1,9 @@
 package entity
 
 import (
+	"context"
+	"next-learn-go/tenant"
+
 	"github.com/google/uuid"
 	"github.com/uptrace/bun"
 )
@@ -8,14 +11,37 @@ import (
 type Customer struct {
 	bun.BaseModel `bun:"customers,alias:c"`
 
-	ID            uuid.UUID `json:"id" bun:"type:char(36),default:uuid(),pk"`
-	Name          string    `json:"name" bun:",notnull,type:varchar(45)"`
-	Email         string    `json:"email" bun:",notnull,type:varchar(255)"`
-	ImageUrl      string    `json:"image_url" bun:"type:varchar(255)"`
-	Invoices      []Invoice `bun:"rel:has-many,join:id=customer_id"`
-	TotalInvoices uint      `json:"total_invoices" bun:",scanonly"`
-	TotalPending  uint      `json:"total_pending" bun:",scanonly"`
-	TotalPaid     uint      `json:"total_paid" bun:",scanonly"`
+	ID             uuid.UUID `json:"id" bun:"type:char(36),default:uuid(),pk"`
+	OrganizationId uuid.UUID `json:"-" bun:"type:char(36),notnull"`
+	Name           string    `json:"name" bun:",notnull,type:varchar(45)"`
+	Email          string    `json:"email" bun:",notnull,type:varchar(255)"`
+	ImageUrl       string    `json:"image_url" bun:"type:varchar(255)"`
+	PaymentTerms   string    `json:"payment_terms" bun:",notnull,type:varchar(20)"`
+	Invoices       []Invoice `bun:"rel:has-many,join:id=customer_id"`
+	TotalInvoices  uint      `json:"total_invoices" bun:",scanonly"`
+	TotalPending   uint      `json:"total_pending" bun:",scanonly"`
+	TotalPaid      uint      `json:"total_paid" bun:",scanonly"`
+	TotalCredited  uint      `json:"total_credited" bun:",scanonly"`
+	TotalRefunded  uint      `json:"total_refunded" bun:",scanonly"`
+}
+
+func (*Customer) BeforeSelect(ctx context.Context, q *bun.SelectQuery) error {
+	return tenant.Select(ctx, q)
+}
+
+func (*Customer) BeforeUpdate(ctx context.Context, q *bun.UpdateQuery) error {
+	return tenant.Update(ctx, q)
+}
+
+func (*Customer) BeforeDelete(ctx context.Context, q *bun.DeleteQuery) error {
+	return tenant.Delete(ctx, q)
+}
+
+func (c *Customer) BeforeAppendModel(ctx context.Context, q bun.Query) error {
+	if _, ok := q.(*bun.InsertQuery); ok {
+		return tenant.Assign(ctx, &c.OrganizationId)
+	}
+	return nil
 }
 
 type GetAllCustomerResponse struct {
@@ -31,4 +57,14 @@ type GetFilteredCustomerResponse struct {
 	TotalInvoices uint      `json:"total_invoices"`
 	TotalPending  uint      `json:"total_pending"`
 	TotalPaid     uint      `json:"total_paid"`
+	TotalCredited uint      `json:"total_credited"`
+	TotalRefunded uint      `json:"total_refunded"`
+}
+
+type CustomerResponse struct {
+	ID           uuid.UUID `json:"id"`
+	Name         string    `json:"name"`
+	Email        string    `json:"email"`
+	ImageUrl     string    `json:"image_url"`
+	PaymentTerms string    `json:"payment_terms"`
 }
@@ -1,6 +1,8 @@
 package entity
 
 import (
+	"context"
+	"next-learn-go/tenant"
 	"time"
 
 	"github.com/google/uuid"
@@ -10,16 +12,51 @@ import (
 type Invoice struct {
 	bun.BaseModel `bun:"invoices,alias:i"`
 
-	ID         uuid.UUID `json:"id" bun:"type:char(36),default:uuid(),pk"`
-	Amount     int       `json:"amount" bun:",notnull"`
-	Status     string    `json:"status" bun:",notnull"`
-	Date       time.Time `json:"date" bun:",nullzero,notnull"`
-	Customer   Customer  `json:"customer" bun:"rel:belongs-to,join:customer_id=id"`
-	CustomerId uuid.UUID `json:"customer_id" bun:"type:char(36),default:uuid()"`
+	ID             uuid.UUID     `json:"id" bun:"type:char(36),default:uuid(),pk"`
+	OrganizationId uuid.UUID     `json:"-" bun:"type:char(36),notnull"`
+	Number         string        `json:"number" bun:",notnull,type:varchar(50)"`
+	Subtotal       int           `json:"subtotal" bun:",notnull"`
+	Tax            int           `json:"tax" bun:",notnull"`
+	Amount         int           `json:"amount" bun:",notnull"`
+	Status         string        `json:"status" bun:",notnull"`
+	Date           time.Time     `json:"date" bun:",nullzero,notnull"`
+	PaymentTerms   string        `json:"payment_terms" bun:",notnull,type:varchar(20)"`
+	DueDate        time.Time     `json:"due_date" bun:",nullzero,notnull"`
+	Customer       Customer      `json:"customer" bun:"rel:belongs-to,join:customer_id=id"`
+	CustomerId     uuid.UUID     `json:"customer_id" bun:"type:char(36),default:uuid()"`
+	Items          []InvoiceItem `json:"items" bun:"rel:has-many,join:id=invoice_id"`
+
+	// 定期請求から作られた請求書は、ひな形と請求日の組で一意になる
+	RecurringInvoiceId uuid.NullUUID `json:"-" bun:"type:char(36)"`
+	RecurrenceDate     bun.NullTime  `json:"-"`
+
+	StatusHistory []InvoiceStatusChange `json:"-" bun:"rel:has-many,join:id=invoice_id"`
+	Payments      []Payment             `json:"-" bun:"rel:has-many,join:id=invoice_id"`
+	CreditNotes   []CreditNote          `json:"-" bun:"rel:has-many,join:id=invoice_id"`
+}
+
+func (*Invoice) BeforeSelect(ctx context.Context, q *bun.SelectQuery) error {
+	return tenant.Select(ctx, q)
+}
+
+func (*Invoice) BeforeUpdate(ctx context.Context, q *bun.UpdateQuery) error {
+	return tenant.Update(ctx, q)
+}
+
+func (*Invoice) BeforeDelete(ctx context.Context, q *bun.DeleteQuery) error {
+	return tenant.Delete(ctx, q)
+}
+
+func (i *Invoice) BeforeAppendModel(ctx context.Context, q bun.Query) error {
+	if _, ok := q.(*bun.InsertQuery); ok {
+		return tenant.Assign(ctx, &i.OrganizationId)
+	}
+	return nil
 }
 
 type GetLatestInvoicesResponse struct {
 	ID       uuid.UUID `json:"id"`
+	Number   string    `json:"number"`
 	Name     string    `json:"name"`
 	ImageUrl string    `json:"image_url"`
 	Email    string    `json:"email"`
@@ -27,31 +64,85 @@ type GetLatestInvoicesResponse struct {
 }
 
 type GetFilteredInvoicesResponse struct {
-	ID         uuid.UUID `json:"id"`
-	CustomerId uuid.UUID `json:"customer_id"`
-	Name       string    `json:"name"`
-	Email      string    `json:"email"`
-	ImageUrl   string    `json:"image_url"`
-	Amount     int       `json:"amount"`
-	Date       time.Time `json:"date"`
-	Status     string    `json:"status"`
+	ID          uuid.UUID `json:"id"`
+	Number      string    `json:"number"`
+	CustomerId  uuid.UUID `json:"customer_id"`
+	Name        string    `json:"name"`
+	Email       string    `json:"email"`
+	ImageUrl    string    `json:"image_url"`
+	Amount      int       `json:"amount"`
+	Date        time.Time `json:"date"`
+	DueDate     time.Time `json:"due_date"`
+	DaysOverdue int       `json:"days_overdue"`
+	Status      string    `json:"status"`
 }
 
 type GetInvoiceByIdResponse struct {
-	ID         uuid.UUID `json:"id"`
-	CustomerId uuid.UUID `json:"customer_id"`
-	Amount     int       `json:"amount"`
-	Status     string    `json:"status"`
+	ID             uuid.UUID             `json:"id"`
+	Number         string                `json:"number"`
+	CustomerId     uuid.UUID             `json:"customer_id"`
+	Subtotal       int                   `json:"subtotal"`
+	Tax            int                   `json:"tax"`
+	Amount         int                   `json:"amount"`
+	Status         string                `json:"status"`
+	PaymentTerms   string                `json:"payment_terms"`
+	DueDate        time.Time             `json:"due_date"`
+	AmountPaid     int                   `json:"amount_paid"`
+	AmountCredited int                   `json:"amount_credited"`
+	BalanceDue     int                   `json:"balance_due"`
+	Items          []InvoiceItemResponse `json:"items"`
+
+	StatusHistory []InvoiceStatusChangeResponse `json:"status_history"`
+	CreditNotes   []CreditNoteResponse          `json:"credit_notes"`
+
+	RecurringInvoiceId *uuid.UUID `json:"recurring_invoice_id,omitempty"`
 }
 
 type InvoiceResponse struct {
-	ID       uuid.UUID `json:"id"`
-	Amount   int       `json:"amount"`
-	Date     time.Time `json:"date"`
-	Status   string    `json:"status"`
-	Customer struct {
+	ID           uuid.UUID             `json:"id"`
+	Number       string                `json:"number"`
+	Subtotal     int                   `json:"subtotal"`
+	Tax          int                   `json:"tax"`
+	Amount       int                   `json:"amount"`
+	Date         time.Time             `json:"date"`
+	PaymentTerms string                `json:"payment_terms"`
+	DueDate      time.Time             `json:"due_date"`
+	Status       string                `json:"status"`
+	Items        []InvoiceItemResponse `json:"items"`
+	Customer     struct {
 		Name     string `json:"name"`
 		Email    string `json:"email"`
 		ImageUrl string `json:"image_url"`
 	} `json:"customer"`
 }
+
+// Invoice listings can be sorted by these fields, e.g. "-amount,date".
+const (
+	InvoiceSortDate    = "date"
+	InvoiceSortDueDate = "due_date"
+	InvoiceSortAmount  = "amount"
+	InvoiceSortStatus  = "status"
+	InvoiceSortName    = "name"
+	InvoiceSortEmail   = "email"
+	InvoiceSortNumber  = "number"
+)
+
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// InvoiceFilter narrows an invoice listing. Zero fields do not filter; the
+// date range is inclusive. Overdue keeps the outstanding invoices past their
+// due date. Without Sort, invoices are listed newest first.
+type InvoiceFilter struct {
+	Query      string
+	Status     string
+	Overdue    bool
+	CustomerId uuid.UUID
+	AmountMin  *int
+	AmountMax  *int
+	DateFrom   time.Time
+	DateTo     time.Time
+	Sort       []SortField
+}
@@ -0,0 +1,32 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+type InvoiceItem struct {
+	bun.BaseModel `bun:"invoice_items,alias:ii"`
+
+	ID          uuid.UUID `json:"id" bun:"type:char(36),default:uuid(),pk"`
+	InvoiceId   uuid.UUID `json:"invoice_id" bun:"type:char(36),notnull"`
+	Position    int       `json:"position" bun:",notnull"`
+	Description string    `json:"description" bun:",notnull,type:varchar(255)"`
+	Quantity    int       `json:"quantity" bun:",notnull"`
+	UnitPrice   int       `json:"unit_price" bun:",notnull"`
+	TaxRate     float64   `json:"tax_rate" bun:",notnull,type:numeric(6,3)"`
+	Subtotal    int       `json:"subtotal" bun:",notnull"`
+	Tax         int       `json:"tax" bun:",notnull"`
+	Total       int       `json:"total" bun:",notnull"`
+}
+
+type InvoiceItemResponse struct {
+	ID          uuid.UUID `json:"id"`
+	Description string    `json:"description"`
+	Quantity    int       `json:"quantity"`
+	UnitPrice   int       `json:"unit_price"`
+	TaxRate     float64   `json:"tax_rate"`
+	Subtotal    int       `json:"subtotal"`
+	Tax         int       `json:"tax"`
+	Total       int       `json:"total"`
+}
@@ -0,0 +1,92 @@
+package entity
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultInvoiceNumberFormat numbers invoices per year, e.g. INV-2024-00042.
+const DefaultInvoiceNumberFormat = "INV-{YYYY}-{seq:05}"
+
+// InvoiceNumberMaxLength is the length of the invoices.number column.
+const InvoiceNumberMaxLength = 50
+
+var (
+	invoiceNumberToken   = regexp.MustCompile(`\{[^{}]*\}`)
+	invoiceNumberSeq     = regexp.MustCompile(`^seq(?::0([1-9]|1[0-9]))?$`)
+	invoiceNumberLiteral = regexp.MustCompile(`^[A-Za-z0-9._-]*$`)
+)
+
+// InvoiceNumbering turns an invoice's place in its sequence into its number.
+// Format may contain {YYYY} or {YY} for the year of the invoice date and must
+// contain one {seq}, optionally zero padded as in {seq:05}. With ResetYearly
+// each year has its own sequence starting from 1.
+type InvoiceNumbering struct {
+	Format      string
+	ResetYearly bool
+}
+
+func DefaultInvoiceNumbering() InvoiceNumbering {
+	return InvoiceNumbering{Format: DefaultInvoiceNumberFormat, ResetYearly: true}
+}
+
+// ParseInvoiceNumbering checks that format produces numbers that fit the
+// column, can be used in a URL and, with resetYearly, differ between years.
+func ParseInvoiceNumbering(format string, resetYearly bool) (InvoiceNumbering, error) {
+	seqs, years := 0, 0
+	for _, token := range invoiceNumberToken.FindAllString(format, -1) {
+		switch name := strings.Trim(token, "{}"); {
+		case name == "YYYY" || name == "YY":
+			years++
+		case invoiceNumberSeq.MatchString(name):
+			seqs++
+		default:
+			return InvoiceNumbering{}, fmt.Errorf("invoice number format %q: unknown placeholder %s", format, token)
+		}
+	}
+	if seqs != 1 {
+		return InvoiceNumbering{}, fmt.Errorf("invoice number format %q: must contain {seq} exactly once", format)
+	}
+	if resetYearly && years == 0 {
+		return InvoiceNumbering{}, fmt.Errorf("invoice number format %q: must contain {YYYY} or {YY} to reset yearly", format)
+	}
+	if !invoiceNumberLiteral.MatchString(invoiceNumberToken.ReplaceAllString(format, "")) {
+		return InvoiceNumbering{}, fmt.Errorf("invoice number format %q: only letters, digits, '.', '_' and '-' are allowed", format)
+	}
+	n := InvoiceNumbering{Format: format, ResetYearly: resetYearly}
+	// 連番は bigint だが、現実的な上限として 10 桁まで収まることを確かめる
+	if len(n.Number(time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC), 9999999999)) > InvoiceNumberMaxLength {
+		return InvoiceNumbering{}, fmt.Errorf("invoice number format %q: numbers may exceed %d characters", format, InvoiceNumberMaxLength)
+	}
+	return n, nil
+}
+
+// SequenceName is the number sequence that invoices dated date take their
+// numbers from.
+func (n InvoiceNumbering) SequenceName(date time.Time) string {
+	if n.ResetYearly {
+		return fmt.Sprintf("invoice:%04d", date.Year())
+	}
+	return "invoice"
+}
+
+// Number formats the seq-th number of the sequence for an invoice dated date.
+func (n InvoiceNumbering) Number(date time.Time, seq int64) string {
+	return invoiceNumberToken.ReplaceAllStringFunc(n.Format, func(token string) string {
+		name := strings.Trim(token, "{}")
+		switch name {
+		case "YYYY":
+			return fmt.Sprintf("%04d", date.Year())
+		case "YY":
+			return fmt.Sprintf("%02d", date.Year()%100)
+		}
+		width := 0
+		if match := invoiceNumberSeq.FindStringSubmatch(name); match != nil && match[1] != "" {
+			width, _ = strconv.Atoi(match[1])
+		}
+		return fmt.Sprintf("%0*d", width, seq)
+	})
+}
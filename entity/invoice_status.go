@@ -0,0 +1,71 @@
+package entity
+
+import (
+	"context"
+	"database/sql"
+	"next-learn-go/tenant"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+const (
+	InvoiceStatusDraft         = "draft"
+	InvoiceStatusSent          = "sent"
+	InvoiceStatusPending       = "pending"
+	InvoiceStatusPartiallyPaid = "partially_paid"
+	InvoiceStatusPaid          = "paid"
+	InvoiceStatusOverdue       = "overdue"
+	InvoiceStatusVoid          = "void"
+)
+
+// InvoiceStatuses lists every status an invoice can be in.
+var InvoiceStatuses = []string{
+	InvoiceStatusDraft,
+	InvoiceStatusSent,
+	InvoiceStatusPending,
+	InvoiceStatusPartiallyPaid,
+	InvoiceStatusPaid,
+	InvoiceStatusOverdue,
+	InvoiceStatusVoid,
+}
+
+// OutstandingInvoiceStatuses are the statuses of invoices that have been
+// issued and still await payment.
+var OutstandingInvoiceStatuses = []string{
+	InvoiceStatusSent,
+	InvoiceStatusPending,
+	InvoiceStatusPartiallyPaid,
+	InvoiceStatusOverdue,
+}
+
+// InvoiceStatusChange records one transition of an invoice's status.
+// FromStatus is null for the status an invoice was created with.
+type InvoiceStatusChange struct {
+	bun.BaseModel `bun:"invoice_status_history,alias:ish"`
+
+	ID             uuid.UUID      `bun:"type:char(36),default:uuid(),pk"`
+	OrganizationId uuid.UUID      `bun:"type:char(36),notnull"`
+	InvoiceId      uuid.UUID      `bun:"type:char(36),notnull"`
+	FromStatus     sql.NullString `bun:"type:varchar(20)"`
+	ToStatus       string         `bun:",notnull,type:varchar(20)"`
+	ChangedAt      time.Time      `bun:",nullzero,notnull,default:current_timestamp"`
+}
+
+func (*InvoiceStatusChange) BeforeSelect(ctx context.Context, q *bun.SelectQuery) error {
+	return tenant.Select(ctx, q)
+}
+
+func (c *InvoiceStatusChange) BeforeAppendModel(ctx context.Context, q bun.Query) error {
+	if _, ok := q.(*bun.InsertQuery); ok {
+		return tenant.Assign(ctx, &c.OrganizationId)
+	}
+	return nil
+}
+
+type InvoiceStatusChangeResponse struct {
+	FromStatus string    `json:"from_status,omitempty"`
+	ToStatus   string    `json:"to_status"`
+	ChangedAt  time.Time `json:"changed_at"`
+}
@@ -0,0 +1,45 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+type Organization struct {
+	bun.BaseModel `bun:"organizations,alias:o"`
+
+	ID        uuid.UUID `json:"id" bun:"type:char(36),default:uuid(),pk"`
+	Name      string    `json:"name" bun:",notnull,type:varchar(255)"`
+	CreatedAt time.Time `json:"created_at" bun:",nullzero,notnull,default:current_timestamp"`
+}
+
+type OrganizationMember struct {
+	bun.BaseModel `bun:"organization_members,alias:om"`
+
+	OrganizationId uuid.UUID     `json:"organization_id" bun:"type:char(36),pk"`
+	UserId         uuid.UUID     `json:"user_id" bun:"type:char(36),pk"`
+	Role           string        `json:"role" bun:",notnull,type:varchar(20)"`
+	CreatedAt      time.Time     `json:"created_at" bun:",nullzero,notnull,default:current_timestamp"`
+	Organization   *Organization `json:"organization" bun:"rel:belongs-to,join:organization_id=id"`
+	User           *User         `json:"user" bun:"rel:belongs-to,join:user_id=id"`
+}
+
+type OrganizationResponse struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+	Role string    `json:"role"`
+}
+
+type AddOrganizationMemberRequest struct {
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+type OrganizationMemberResponse struct {
+	UserId uuid.UUID `json:"user_id"`
+	Name   string    `json:"name"`
+	Email  string    `json:"email"`
+	Role   string    `json:"role"`
+}
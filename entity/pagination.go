@@ -0,0 +1,37 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Cursor is a position in a listing ordered by (date DESC, id DESC). A
+// forward cursor selects the rows after it; a backward cursor the rows before
+// it. The zero Cursor selects the first page.
+type Cursor struct {
+	Date     time.Time
+	ID       uuid.UUID
+	Backward bool
+}
+
+func (c Cursor) IsZero() bool {
+	return c.ID == uuid.Nil
+}
+
+type PageLinks struct {
+	Next string `json:"next,omitempty"`
+	Prev string `json:"prev,omitempty"`
+}
+
+// CursorPage is one page of a keyset-paginated listing. NextCursor and
+// PrevCursor are opaque tokens, empty when there is no page in that
+// direction. Total and TotalPages are only set when requested.
+type CursorPage[T any] struct {
+	Data       []T       `json:"data"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+	PrevCursor string    `json:"prev_cursor,omitempty"`
+	Links      PageLinks `json:"links"`
+	Total      *int      `json:"total,omitempty"`
+	TotalPages *int      `json:"total_pages,omitempty"`
+}
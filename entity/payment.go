@@ -0,0 +1,137 @@
+package entity
+
+import (
+	"context"
+	"next-learn-go/tenant"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+const (
+	PaymentMethodBankTransfer = "bank_transfer"
+	PaymentMethodCard         = "card"
+	PaymentMethodCash         = "cash"
+	PaymentMethodCheck        = "check"
+	PaymentMethodOther        = "other"
+)
+
+// Payment is money received towards an invoice. An invoice may be settled by
+// several payments.
+type Payment struct {
+	bun.BaseModel `bun:"payments,alias:p"`
+
+	ID             uuid.UUID `json:"id" bun:"type:char(36),default:uuid(),pk"`
+	OrganizationId uuid.UUID `json:"-" bun:"type:char(36),notnull"`
+	InvoiceId      uuid.UUID `json:"invoice_id" bun:"type:char(36),notnull"`
+	Amount         int       `json:"amount" bun:",notnull"`
+	Method         string    `json:"method" bun:",notnull,type:varchar(20)"`
+	Reference      string    `json:"reference" bun:",notnull,type:varchar(255)"`
+	ReceivedAt     time.Time `json:"received_at" bun:",nullzero,notnull"`
+	CreatedAt      time.Time `json:"-" bun:",nullzero,notnull,default:current_timestamp"`
+	Refunds        []Refund  `json:"-" bun:"rel:has-many,join:id=payment_id"`
+}
+
+func (*Payment) BeforeSelect(ctx context.Context, q *bun.SelectQuery) error {
+	return tenant.Select(ctx, q)
+}
+
+func (*Payment) BeforeDelete(ctx context.Context, q *bun.DeleteQuery) error {
+	return tenant.Delete(ctx, q)
+}
+
+func (p *Payment) BeforeAppendModel(ctx context.Context, q bun.Query) error {
+	if _, ok := q.(*bun.InsertQuery); ok {
+		return tenant.Assign(ctx, &p.OrganizationId)
+	}
+	return nil
+}
+
+// Refund is money returned against a payment.
+type Refund struct {
+	bun.BaseModel `bun:"refunds,alias:rf"`
+
+	ID             uuid.UUID `json:"id" bun:"type:char(36),default:uuid(),pk"`
+	OrganizationId uuid.UUID `json:"-" bun:"type:char(36),notnull"`
+	PaymentId      uuid.UUID `json:"payment_id" bun:"type:char(36),notnull"`
+	Amount         int       `json:"amount" bun:",notnull"`
+	Reason         string    `json:"reason" bun:",notnull,type:varchar(255)"`
+	RefundedAt     time.Time `json:"refunded_at" bun:",nullzero,notnull"`
+	CreatedAt      time.Time `json:"-" bun:",nullzero,notnull,default:current_timestamp"`
+}
+
+func (*Refund) BeforeSelect(ctx context.Context, q *bun.SelectQuery) error {
+	return tenant.Select(ctx, q)
+}
+
+func (rf *Refund) BeforeAppendModel(ctx context.Context, q bun.Query) error {
+	if _, ok := q.(*bun.InsertQuery); ok {
+		return tenant.Assign(ctx, &rf.OrganizationId)
+	}
+	return nil
+}
+
+// InvoiceBalance is what has been received, refunded and credited against an
+// invoice.
+type InvoiceBalance struct {
+	Paid     int `bun:"paid"`
+	Refunded int `bun:"refunded"`
+	Credited int `bun:"credited"`
+}
+
+// NewInvoiceBalance sums the payments, refunds and credit notes loaded with
+// invoice.
+func NewInvoiceBalance(invoice Invoice) InvoiceBalance {
+	balance := InvoiceBalance{}
+	for _, payment := range invoice.Payments {
+		balance.Paid += payment.Amount
+		for _, refund := range payment.Refunds {
+			balance.Refunded += refund.Amount
+		}
+	}
+	for _, creditNote := range invoice.CreditNotes {
+		balance.Credited += creditNote.Amount
+	}
+	return balance
+}
+
+// NetPaid is what the customer has paid and kept paid.
+func (b InvoiceBalance) NetPaid() int {
+	return b.Paid - b.Refunded
+}
+
+// Due is what remains owed on an invoice of amount.
+func (b InvoiceBalance) Due(amount int) int {
+	return amount - b.NetPaid() - b.Credited
+}
+
+type PaymentResponse struct {
+	ID             uuid.UUID        `json:"id"`
+	InvoiceId      uuid.UUID        `json:"invoice_id"`
+	Amount         int              `json:"amount"`
+	Method         string           `json:"method"`
+	Reference      string           `json:"reference"`
+	ReceivedAt     time.Time        `json:"received_at"`
+	AmountRefunded int              `json:"amount_refunded"`
+	Refunds        []RefundResponse `json:"refunds"`
+}
+
+type RefundResponse struct {
+	ID         uuid.UUID `json:"id"`
+	PaymentId  uuid.UUID `json:"payment_id"`
+	Amount     int       `json:"amount"`
+	Reason     string    `json:"reason"`
+	RefundedAt time.Time `json:"refunded_at"`
+}
+
+// InvoicePaymentsResponse is the payment ledger of one invoice.
+type InvoicePaymentsResponse struct {
+	InvoiceId      uuid.UUID         `json:"invoice_id"`
+	Status         string            `json:"status"`
+	Amount         int               `json:"amount"`
+	AmountPaid     int               `json:"amount_paid"`
+	AmountCredited int               `json:"amount_credited"`
+	BalanceDue     int               `json:"balance_due"`
+	Payments       []PaymentResponse `json:"payments"`
+}
@@ -0,0 +1,21 @@
+package entity
+
+// Payment terms decide when an invoice falls due. Customers carry one of the
+// net terms as the default for their invoices; an invoice can instead be
+// given a custom due date.
+const (
+	PaymentTermsNet15  = "net_15"
+	PaymentTermsNet30  = "net_30"
+	PaymentTermsNet60  = "net_60"
+	PaymentTermsCustom = "custom"
+
+	DefaultPaymentTerms = PaymentTermsNet30
+)
+
+// PaymentTermDays is the number of days after the issue date that an invoice
+// under each net term is due.
+var PaymentTermDays = map[string]int{
+	PaymentTermsNet15: 15,
+	PaymentTermsNet30: 30,
+	PaymentTermsNet60: 60,
+}
@@ -0,0 +1,90 @@
+package entity
+
+import (
+	"context"
+	"next-learn-go/tenant"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+const (
+	RecurrenceMonthly   = "monthly"
+	RecurrenceQuarterly = "quarterly"
+	RecurrenceYearly    = "yearly"
+)
+
+// RecurrenceMonths is the number of months between the invoices of each
+// frequency.
+var RecurrenceMonths = map[string]int{
+	RecurrenceMonthly:   1,
+	RecurrenceQuarterly: 3,
+	RecurrenceYearly:    12,
+}
+
+// RecurringInvoice is a template from which an invoice is created on the same
+// day of the month every month, quarter or year from StartDate until EndDate.
+type RecurringInvoice struct {
+	bun.BaseModel `bun:"recurring_invoices,alias:ri"`
+
+	ID              uuid.UUID    `json:"id" bun:"type:char(36),default:uuid(),pk"`
+	OrganizationId  uuid.UUID    `json:"-" bun:"type:char(36),notnull"`
+	CustomerId      uuid.UUID    `json:"customer_id" bun:"type:char(36),notnull"`
+	Customer        Customer     `json:"-" bun:"rel:belongs-to,join:customer_id=id"`
+	Description     string       `json:"description" bun:",notnull,type:varchar(255)"`
+	Amount          int          `json:"amount" bun:",notnull"`
+	TaxRate         float64      `json:"tax_rate" bun:",notnull,type:numeric(6,3)"`
+	Frequency       string       `json:"frequency" bun:",notnull,type:varchar(20)"`
+	DayOfMonth      int          `json:"day_of_month" bun:",notnull"`
+	StartDate       time.Time    `json:"start_date" bun:",nullzero,notnull"`
+	EndDate         bun.NullTime `json:"end_date"`
+	NextDate        bun.NullTime `json:"-"`
+	LastInvoiceDate bun.NullTime `json:"-"`
+	CreatedAt       time.Time    `json:"-" bun:",nullzero,notnull,default:current_timestamp"`
+}
+
+func (*RecurringInvoice) BeforeSelect(ctx context.Context, q *bun.SelectQuery) error {
+	return tenant.Select(ctx, q)
+}
+
+func (*RecurringInvoice) BeforeUpdate(ctx context.Context, q *bun.UpdateQuery) error {
+	return tenant.Update(ctx, q)
+}
+
+func (*RecurringInvoice) BeforeDelete(ctx context.Context, q *bun.DeleteQuery) error {
+	return tenant.Delete(ctx, q)
+}
+
+func (ri *RecurringInvoice) BeforeAppendModel(ctx context.Context, q bun.Query) error {
+	if _, ok := q.(*bun.InsertQuery); ok {
+		return tenant.Assign(ctx, &ri.OrganizationId)
+	}
+	return nil
+}
+
+type RecurringInvoiceResponse struct {
+	ID              uuid.UUID  `json:"id"`
+	CustomerId      uuid.UUID  `json:"customer_id"`
+	Description     string     `json:"description"`
+	Amount          int        `json:"amount"`
+	TaxRate         float64    `json:"tax_rate"`
+	Frequency       string     `json:"frequency"`
+	DayOfMonth      int        `json:"day_of_month"`
+	StartDate       time.Time  `json:"start_date"`
+	EndDate         *time.Time `json:"end_date"`
+	NextDate        *time.Time `json:"next_date"`
+	LastInvoiceDate *time.Time `json:"last_invoice_date"`
+}
+
+// RecurringInvoiceOccurrence is an invoice a recurring invoice will create.
+type RecurringInvoiceOccurrence struct {
+	Date    time.Time `json:"date"`
+	DueDate time.Time `json:"due_date"`
+	Amount  int       `json:"amount"`
+}
+
+type RecurringInvoicePreviewResponse struct {
+	RecurringInvoiceId uuid.UUID                    `json:"recurring_invoice_id"`
+	Occurrences        []RecurringInvoiceOccurrence `json:"occurrences"`
+}
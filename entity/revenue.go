@@ -1,12 +1,53 @@
 package entity
 
 import (
-	"github.com/uptrace/bun"
+	"time"
 )
 
+const (
+	GranularityDay     = "day"
+	GranularityWeek    = "week"
+	GranularityMonth   = "month"
+	GranularityQuarter = "quarter"
+	GranularityYear    = "year"
+)
+
+// Revenue is the legacy dashboard shape: a short month label and the paid
+// total in whole dollars.
 type Revenue struct {
-	bun.BaseModel `bun:"revenue,alias:r"`
+	Month   string `json:"month"`
+	Revenue int    `json:"revenue"`
+}
+
+// RevenuePeriod is the paid invoice total of one period starting at
+// PeriodStart less the credit notes issued against those invoices in the
+// period, in cents. Refunded is what was refunded in the period.
+type RevenuePeriod struct {
+	PeriodStart time.Time `bun:"period_start"`
+	Revenue     int       `bun:"revenue"`
+	Credited    int       `bun:"credited"`
+	Refunded    int       `bun:"refunded"`
+}
+
+type RevenueQuery struct {
+	From        time.Time
+	To          time.Time
+	Granularity string
+}
+
+type RevenuePeriodResponse struct {
+	PeriodStart string `json:"period_start"`
+	Revenue     int    `json:"revenue"`
+	Credited    int    `json:"credited"`
+	Refunded    int    `json:"refunded"`
+}
 
-	Month   string `json:"month" bun:",type:varchar(4),notnull,unique"`
-	Revenue int    `json:"revenue" bun:",notnull"`
+type RevenueAggregateResponse struct {
+	From        string                  `json:"from"`
+	To          string                  `json:"to"`
+	Granularity string                  `json:"granularity"`
+	Total       int                     `json:"total"`
+	Credited    int                     `json:"credited"`
+	Refunded    int                     `json:"refunded"`
+	Periods     []RevenuePeriodResponse `json:"periods"`
 }
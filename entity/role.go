@@ -0,0 +1,60 @@
+package entity
+
+const (
+	RoleAdmin      = "admin"
+	RoleAccountant = "accountant"
+	RoleViewer     = "viewer"
+)
+
+var Roles = []string{RoleAdmin, RoleAccountant, RoleViewer}
+
+type Permission string
+
+const (
+	PermissionInvoicesRead    Permission = "invoices:read"
+	PermissionInvoicesWrite   Permission = "invoices:write"
+	PermissionInvoicesDelete  Permission = "invoices:delete"
+	PermissionCustomersRead   Permission = "customers:read"
+	PermissionCustomersWrite  Permission = "customers:write"
+	PermissionCustomersDelete Permission = "customers:delete"
+	PermissionRevenuesRead    Permission = "revenues:read"
+	PermissionUsersManage     Permission = "users:manage"
+)
+
+var rolePermissions = map[string][]Permission{
+	RoleViewer: {
+		PermissionInvoicesRead,
+		PermissionCustomersRead,
+		PermissionRevenuesRead,
+	},
+	RoleAccountant: {
+		PermissionInvoicesRead,
+		PermissionInvoicesWrite,
+		PermissionCustomersRead,
+		PermissionCustomersWrite,
+		PermissionRevenuesRead,
+	},
+	RoleAdmin: {
+		PermissionInvoicesRead,
+		PermissionInvoicesWrite,
+		PermissionInvoicesDelete,
+		PermissionCustomersRead,
+		PermissionCustomersWrite,
+		PermissionCustomersDelete,
+		PermissionRevenuesRead,
+		PermissionUsersManage,
+	},
+}
+
+func HasPermission(role string, permission Permission) bool {
+	for _, p := range rolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+type UpdateUserRoleRequest struct {
+	Role string `json:"role"`
+}
@@ -0,0 +1,55 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	SearchHitCustomer = "customer"
+	SearchHitInvoice  = "invoice"
+)
+
+type SearchQuery struct {
+	Query string
+	Limit int
+}
+
+type CustomerSearchResult struct {
+	ID       uuid.UUID `json:"id"`
+	Name     string    `json:"name"`
+	Email    string    `json:"email"`
+	ImageUrl string    `json:"image_url"`
+	Score    float64   `json:"-"`
+}
+
+// InvoiceSearchResult is an invoice found through its customer or its line
+// items. MatchedItems lists the descriptions of the items that matched.
+type InvoiceSearchResult struct {
+	ID            uuid.UUID `json:"id"`
+	Amount        int       `json:"amount"`
+	Status        string    `json:"status"`
+	Date          time.Time `json:"date"`
+	CustomerName  string    `json:"customer_name"`
+	CustomerEmail string    `json:"customer_email"`
+	MatchedItems  string    `json:"matched_items,omitempty"`
+	Score         float64   `json:"-"`
+}
+
+// SearchHit is one customer or invoice in the search results. Highlights maps
+// field names to their HTML-escaped text with the matched terms wrapped in
+// <mark>; fields without a literal match are left out.
+type SearchHit struct {
+	Type       string                `json:"type"`
+	ID         uuid.UUID             `json:"id"`
+	Score      float64               `json:"score"`
+	Highlights map[string]string     `json:"highlights"`
+	Customer   *CustomerSearchResult `json:"customer,omitempty"`
+	Invoice    *InvoiceSearchResult  `json:"invoice,omitempty"`
+}
+
+type SearchResponse struct {
+	Query string      `json:"query"`
+	Hits  []SearchHit `json:"hits"`
+}
@@ -0,0 +1,16 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// NumberSequence is the last number handed out from one of an organization's
+// document number sequences, such as credit note numbers.
+type NumberSequence struct {
+	bun.BaseModel `bun:"number_sequences,alias:ns"`
+
+	OrganizationId uuid.UUID `bun:"type:char(36),pk"`
+	Name           string    `bun:",pk,type:varchar(50)"`
+	LastValue      int64     `bun:",notnull"`
+}
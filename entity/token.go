@@ -0,0 +1,26 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+type RefreshToken struct {
+	bun.BaseModel `bun:"refresh_tokens,alias:rt"`
+
+	ID             uuid.UUID     `json:"id" bun:"type:char(36),default:uuid(),pk"`
+	UserId         uuid.UUID     `json:"user_id" bun:"type:char(36),notnull"`
+	OrganizationId uuid.UUID     `json:"organization_id" bun:"type:char(36),notnull"`
+	FamilyId       uuid.UUID     `json:"family_id" bun:"type:char(36),notnull"`
+	TokenHash      string        `json:"-" bun:",notnull,type:char(64)"`
+	ExpiresAt      time.Time     `json:"expires_at" bun:",notnull"`
+	RevokedAt      time.Time     `json:"revoked_at" bun:",nullzero"`
+	ReplacedBy     uuid.NullUUID `json:"replaced_by" bun:"type:char(36)"`
+	CreatedAt      time.Time     `json:"created_at" bun:",nullzero,notnull,default:current_timestamp"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
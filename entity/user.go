@@ -1,6 +1,8 @@
 package entity
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/uptrace/bun"
 )
@@ -19,10 +21,28 @@ type UserResponse struct {
 	Name     string    `json:"name"`
 	Email    string    `json:"email"`
 	Password string    `json:"password"`
+	Role     string    `json:"role"`
+}
+
+type SignUpRequest struct {
+	Name             string `json:"name"`
+	Email            string `json:"email"`
+	Password         string `json:"password"`
+	OrganizationName string `json:"organization_name"`
+}
+
+type LoginRequest struct {
+	Email          string    `json:"email"`
+	Password       string    `json:"password"`
+	OrganizationId uuid.UUID `json:"organization_id"`
 }
 
 type LoginResponse struct {
-	ID    uuid.UUID `json:"id"`
-	Email string    `json:"email"`
-	Token string    `json:"token"`
+	ID             uuid.UUID `json:"id"`
+	Email          string    `json:"email"`
+	OrganizationId uuid.UUID `json:"organization_id"`
+	Role           string    `json:"role"`
+	Token          string    `json:"token"`
+	ExpiresAt      time.Time `json:"expires_at"`
+	RefreshToken   string    `json:"refresh_token"`
 }
@@ -0,0 +1,31 @@
+package database
+
+import (
+	"context"
+	"log/slog"
+	"next-learn-go/logger"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// queryLogHook logs every query at debug level with the logger carried by the
+// query context, so queries can be traced back to the request that ran them.
+type queryLogHook struct{}
+
+func (h *queryLogHook) BeforeQuery(ctx context.Context, _ *bun.QueryEvent) context.Context {
+	return ctx
+}
+
+func (h *queryLogHook) AfterQuery(ctx context.Context, event *bun.QueryEvent) {
+	l := logger.FromContext(ctx)
+	if !l.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+	l.DebugContext(ctx, "query",
+		"operation", event.Operation(),
+		"duration_ms", float64(time.Since(event.StartTime).Microseconds())/1000,
+		"query", event.Query,
+		"error", event.Err,
+	)
+}
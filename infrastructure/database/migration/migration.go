@@ -0,0 +1,437 @@
+package migration
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+//go:embed migrations/*.sql
+var migrationFS embed.FS
+
+//go:embed seeds/*.sql
+var seedFS embed.FS
+
+// 複数インスタンスが同時にマイグレーションしないための advisory lock のキー
+const lockKey int64 = 7_461_000_301
+
+var fileNamePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration is one versioned schema change. Checksum covers the up script so
+// that editing an already applied migration is detected instead of silently
+// diverging from the databases it ran on.
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+type MigrationStatus struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+	Modified  bool
+}
+
+type schemaMigration struct {
+	bun.BaseModel `bun:"schema_migrations,alias:sm"`
+
+	Version   int64     `bun:",pk"`
+	Name      string    `bun:",notnull"`
+	Checksum  string    `bun:",notnull"`
+	AppliedAt time.Time `bun:",nullzero,notnull,default:current_timestamp"`
+}
+
+type schemaSeed struct {
+	bun.BaseModel `bun:"schema_seeds,alias:ss"`
+
+	Name      string    `bun:",pk"`
+	Checksum  string    `bun:",notnull"`
+	AppliedAt time.Time `bun:",nullzero,notnull,default:current_timestamp"`
+}
+
+type Migrator interface {
+	Status(ctx context.Context) ([]MigrationStatus, error)
+	Pending(ctx context.Context) ([]Migration, error)
+	Up(ctx context.Context) error
+	Down(ctx context.Context, steps int) error
+	Redo(ctx context.Context) error
+	Seed(ctx context.Context) error
+}
+
+type migrator struct {
+	db         *bun.DB
+	migrations []Migration
+}
+
+func NewMigrator(db *bun.DB) (Migrator, error) {
+	migrations, err := loadMigrations(migrationFS)
+	if err != nil {
+		return nil, err
+	}
+	return &migrator{db, migrations}, nil
+}
+
+func loadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, entry := range entries {
+		match := fileNamePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name: %s", entry.Name())
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		content, err := fs.ReadFile(fsys, path.Join("migrations", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has conflicting names %q and %q", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := []Migration{}
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s must have both up and down files", m.Version, m.Name)
+		}
+		m.Checksum = checksum(m.Up)
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+func checksum(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// Status compares the migration files with the database without writing to
+// it, so it also works on a database that has never been migrated.
+func (m *migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	applied := map[int64]schemaMigration{}
+	exists, err := tableExists(ctx, m.db, "schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		if applied, err = m.applied(ctx, m.db); err != nil {
+			return nil, err
+		}
+	}
+
+	statuses := []MigrationStatus{}
+	for _, v := range m.migrations {
+		s := MigrationStatus{Version: v.Version, Name: v.Name}
+		if a, ok := applied[v.Version]; ok {
+			s.Applied = true
+			s.AppliedAt = a.AppliedAt
+			s.Modified = a.Checksum != v.Checksum
+			delete(applied, v.Version)
+		}
+		statuses = append(statuses, s)
+	}
+	// ファイルが削除された適用済みマイグレーションも表示する
+	for _, a := range applied {
+		statuses = append(statuses, MigrationStatus{Version: a.Version, Name: a.Name, Applied: true, AppliedAt: a.AppliedAt, Modified: true})
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+	return statuses, nil
+}
+
+func (m *migrator) Pending(ctx context.Context) ([]Migration, error) {
+	exists, err := tableExists(ctx, m.db, "schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return m.migrations, nil
+	}
+
+	applied, err := m.applied(ctx, m.db)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.verify(applied); err != nil {
+		return nil, err
+	}
+	pending := []Migration{}
+	for _, v := range m.migrations {
+		if _, ok := applied[v.Version]; !ok {
+			pending = append(pending, v)
+		}
+	}
+	return pending, nil
+}
+
+func (m *migrator) Up(ctx context.Context) error {
+	return m.withLock(ctx, func(conn bun.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := m.verify(applied); err != nil {
+			return err
+		}
+		for _, v := range m.migrations {
+			if _, ok := applied[v.Version]; ok {
+				continue
+			}
+			if err := m.apply(ctx, conn, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (m *migrator) Down(ctx context.Context, steps int) error {
+	return m.withLock(ctx, func(conn bun.Conn) error {
+		return m.rollback(ctx, conn, steps)
+	})
+}
+
+func (m *migrator) Redo(ctx context.Context) error {
+	return m.withLock(ctx, func(conn bun.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := m.verify(applied); err != nil {
+			return err
+		}
+		last, ok := m.lastApplied(applied)
+		if !ok {
+			return fmt.Errorf("no migration has been applied")
+		}
+		if err := m.revert(ctx, conn, last); err != nil {
+			return err
+		}
+		return m.apply(ctx, conn, last)
+	})
+}
+
+// Seed loads the demo data in seeds/. Each file runs once and is recorded in
+// schema_seeds, so it is safe to call on every start. A file that has changed
+// since it ran is not run again, since its rows are already there; a warning
+// says that the database lacks the new demo data. Add new seed files instead
+// of editing applied ones.
+func (m *migrator) Seed(ctx context.Context) error {
+	entries, err := fs.ReadDir(seedFS, "seeds")
+	if err != nil {
+		return err
+	}
+	return m.withLock(ctx, func(conn bun.Conn) error {
+		for _, entry := range entries {
+			content, err := fs.ReadFile(seedFS, path.Join("seeds", entry.Name()))
+			if err != nil {
+				return err
+			}
+			sum := checksum(string(content))
+			applied := schemaSeed{}
+			err = conn.NewSelect().
+				Model(&applied).
+				Where("name=?", entry.Name()).
+				Scan(ctx)
+			if err == nil {
+				if applied.Checksum != sum {
+					slog.Warn("seed has been modified after it was applied; recreate the database to load the current demo data", "file", entry.Name())
+				}
+				continue
+			}
+			if !errors.Is(err, sql.ErrNoRows) {
+				return err
+			}
+			if err := conn.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+				if _, err := tx.Tx.ExecContext(ctx, string(content)); err != nil {
+					return fmt.Errorf("seed %s: %w", entry.Name(), err)
+				}
+				_, err := tx.NewInsert().
+					Model(&schemaSeed{Name: entry.Name(), Checksum: sum}).
+					Exec(ctx)
+				return err
+			}); err != nil {
+				return err
+			}
+			slog.Info("seeded", "file", entry.Name())
+		}
+		return nil
+	})
+}
+
+func (m *migrator) withLock(ctx context.Context, fn func(conn bun.Conn) error) error {
+	// advisory lock はセッション単位なので、同じコネクション上で全ての処理を行う
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock(?)", lockKey); err != nil {
+		return err
+	}
+	defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock(?)", lockKey)
+
+	if err := m.createTables(ctx, conn); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+func (m *migrator) createTables(ctx context.Context, db bun.IDB) error {
+	if _, err := db.NewCreateTable().
+		Model((*schemaMigration)(nil)).
+		IfNotExists().
+		Exec(ctx); err != nil {
+		return err
+	}
+	if _, err := db.NewCreateTable().
+		Model((*schemaSeed)(nil)).
+		IfNotExists().
+		Exec(ctx); err != nil {
+		return err
+	}
+	return nil
+}
+
+func tableExists(ctx context.Context, db bun.IDB, name string) (bool, error) {
+	var exists bool
+	if err := db.NewSelect().
+		ColumnExpr("to_regclass(?) IS NOT NULL", name).
+		Scan(ctx, &exists); err != nil {
+		return false, err
+	}
+	return exists, nil
+}
+
+func (m *migrator) applied(ctx context.Context, db bun.IDB) (map[int64]schemaMigration, error) {
+	rows := []schemaMigration{}
+	if err := db.NewSelect().
+		Model(&rows).
+		Scan(ctx); err != nil {
+		return nil, err
+	}
+	applied := map[int64]schemaMigration{}
+	for _, v := range rows {
+		applied[v.Version] = v
+	}
+	return applied, nil
+}
+
+func (m *migrator) verify(applied map[int64]schemaMigration) error {
+	known := map[int64]Migration{}
+	for _, v := range m.migrations {
+		known[v.Version] = v
+	}
+	for _, a := range applied {
+		v, ok := known[a.Version]
+		if !ok {
+			return fmt.Errorf("migration %d_%s is applied but its files are missing", a.Version, a.Name)
+		}
+		if v.Checksum != a.Checksum {
+			return fmt.Errorf("migration %d_%s has been modified after it was applied", a.Version, a.Name)
+		}
+	}
+	return nil
+}
+
+func (m *migrator) lastApplied(applied map[int64]schemaMigration) (Migration, bool) {
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		if _, ok := applied[m.migrations[i].Version]; ok {
+			return m.migrations[i], true
+		}
+	}
+	return Migration{}, false
+}
+
+func (m *migrator) rollback(ctx context.Context, conn bun.Conn, steps int) error {
+	applied, err := m.applied(ctx, conn)
+	if err != nil {
+		return err
+	}
+	if err := m.verify(applied); err != nil {
+		return err
+	}
+	for i := 0; i < steps; i++ {
+		last, ok := m.lastApplied(applied)
+		if !ok {
+			return nil
+		}
+		if err := m.revert(ctx, conn, last); err != nil {
+			return err
+		}
+		delete(applied, last.Version)
+	}
+	return nil
+}
+
+func (m *migrator) apply(ctx context.Context, conn bun.Conn, v Migration) error {
+	return conn.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		// bun のプレースホルダ展開を避けるため、SQL ファイルは database/sql で直接実行する
+		if _, err := tx.Tx.ExecContext(ctx, v.Up); err != nil {
+			return fmt.Errorf("migration %d_%s up: %w", v.Version, v.Name, err)
+		}
+		if _, err := tx.NewInsert().
+			Model(&schemaMigration{Version: v.Version, Name: v.Name, Checksum: v.Checksum}).
+			Exec(ctx); err != nil {
+			return err
+		}
+		slog.Info("applied migration", "version", v.Version, "name", v.Name)
+		return nil
+	})
+}
+
+func (m *migrator) revert(ctx context.Context, conn bun.Conn, v Migration) error {
+	return conn.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.Tx.ExecContext(ctx, v.Down); err != nil {
+			return fmt.Errorf("migration %d_%s down: %w", v.Version, v.Name, err)
+		}
+		if _, err := tx.NewDelete().
+			Model((*schemaMigration)(nil)).
+			Where("version=?", v.Version).
+			Exec(ctx); err != nil {
+			return err
+		}
+		slog.Info("reverted migration", "version", v.Version, "name", v.Name)
+		return nil
+	})
+}
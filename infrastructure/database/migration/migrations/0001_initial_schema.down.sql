@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS revenue;
+DROP TABLE IF EXISTS invoices;
+DROP TABLE IF EXISTS customers;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,37 @@
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+CREATE TABLE IF NOT EXISTS users (
+    id UUID DEFAULT uuid_generate_v4() PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    email TEXT NOT NULL UNIQUE,
+    password TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS invoices (
+    id UUID DEFAULT uuid_generate_v4() PRIMARY KEY,
+    customer_id UUID NOT NULL,
+    amount INT NOT NULL,
+    status VARCHAR(255) NOT NULL,
+    date DATE NOT NULL
+);
+CREATE TABLE IF NOT EXISTS customers (
+    id UUID DEFAULT uuid_generate_v4() PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    email VARCHAR(255) NOT NULL,
+    image_url VARCHAR(255) NOT NULL
+);
+CREATE TABLE IF NOT EXISTS revenue (
+    month VARCHAR(4) NOT NULL UNIQUE,
+    revenue INT NOT NULL
+);
+-- _tools/first.sql で作成済みのデータベースでも適用できるようにする
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM pg_constraint WHERE conname = 'fk_customer'
+    ) THEN
+        ALTER TABLE invoices
+        ADD CONSTRAINT fk_customer
+        FOREIGN KEY (customer_id)
+        REFERENCES customers(id);
+    END IF;
+END
+$$;
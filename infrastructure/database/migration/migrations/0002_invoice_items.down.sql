@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS invoice_items;
+ALTER TABLE invoices DROP COLUMN IF EXISTS tax;
+ALTER TABLE invoices DROP COLUMN IF EXISTS subtotal;
@@ -0,0 +1,23 @@
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS subtotal INT NOT NULL DEFAULT 0;
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS tax INT NOT NULL DEFAULT 0;
+CREATE TABLE IF NOT EXISTS invoice_items (
+    id UUID DEFAULT uuid_generate_v4() PRIMARY KEY,
+    invoice_id UUID NOT NULL REFERENCES invoices(id) ON DELETE CASCADE,
+    position INT NOT NULL DEFAULT 0,
+    description VARCHAR(255) NOT NULL,
+    quantity INT NOT NULL CHECK (quantity > 0),
+    unit_price INT NOT NULL CHECK (unit_price >= 0),
+    tax_rate NUMERIC(6, 3) NOT NULL DEFAULT 0,
+    subtotal INT NOT NULL,
+    tax INT NOT NULL,
+    total INT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS invoice_items_invoice_id_idx ON invoice_items (invoice_id);
+-- 明細のない既存の請求書は金額全体を 1 行の明細として扱う
+UPDATE invoices SET subtotal = amount WHERE subtotal = 0 AND tax = 0;
+INSERT INTO invoice_items (invoice_id, description, quantity, unit_price, subtotal, tax, total)
+SELECT id, 'Services', 1, amount, amount, 0, amount
+FROM invoices
+WHERE NOT EXISTS (
+        SELECT 1 FROM invoice_items WHERE invoice_items.invoice_id = invoices.id
+    );
@@ -0,0 +1,4 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(20) NOT NULL DEFAULT 'viewer';
+ALTER TABLE users ADD CONSTRAINT users_role_check CHECK (role IN ('admin', 'accountant', 'viewer'));
+-- ロール導入前は全ユーザーが全操作を行えたため、既存ユーザーは管理者として引き継ぐ
+UPDATE users SET role = 'admin';
@@ -0,0 +1,25 @@
+-- 既定の組織以外のデータは単一テナントのスキーマに戻せないため削除する
+DELETE FROM refresh_tokens WHERE organization_id <> '9d3e8a52-6c1f-4b7e-a0d4-2f5c8e1b7a90';
+DELETE FROM revenue WHERE organization_id <> '9d3e8a52-6c1f-4b7e-a0d4-2f5c8e1b7a90';
+DELETE FROM invoices WHERE organization_id <> '9d3e8a52-6c1f-4b7e-a0d4-2f5c8e1b7a90';
+DELETE FROM customers WHERE organization_id <> '9d3e8a52-6c1f-4b7e-a0d4-2f5c8e1b7a90';
+ALTER TABLE refresh_tokens DROP COLUMN IF EXISTS organization_id;
+ALTER TABLE revenue DROP CONSTRAINT IF EXISTS revenue_organization_id_month_key;
+ALTER TABLE revenue DROP COLUMN IF EXISTS organization_id;
+ALTER TABLE revenue ADD CONSTRAINT revenue_month_key UNIQUE (month);
+ALTER TABLE invoices DROP CONSTRAINT IF EXISTS fk_customer;
+ALTER TABLE invoices DROP COLUMN IF EXISTS organization_id;
+ALTER TABLE customers DROP COLUMN IF EXISTS organization_id;
+ALTER TABLE invoices
+ADD CONSTRAINT fk_customer
+FOREIGN KEY (customer_id)
+REFERENCES customers(id);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(20) NOT NULL DEFAULT 'viewer';
+ALTER TABLE users ADD CONSTRAINT users_role_check CHECK (role IN ('admin', 'accountant', 'viewer'));
+UPDATE users
+SET role = organization_members.role
+FROM organization_members
+WHERE organization_members.user_id = users.id
+    AND organization_members.organization_id = '9d3e8a52-6c1f-4b7e-a0d4-2f5c8e1b7a90';
+DROP TABLE IF EXISTS organization_members;
+DROP TABLE IF EXISTS organizations;
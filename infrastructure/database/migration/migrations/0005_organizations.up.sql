@@ -0,0 +1,52 @@
+CREATE TABLE IF NOT EXISTS organizations (
+    id UUID DEFAULT uuid_generate_v4() PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE TABLE IF NOT EXISTS organization_members (
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(20) NOT NULL CHECK (role IN ('admin', 'accountant', 'viewer')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (organization_id, user_id)
+);
+CREATE INDEX IF NOT EXISTS organization_members_user_id_idx ON organization_members (user_id);
+-- 組織導入前のデータとユーザーはすべて既定の組織に引き継ぐ
+INSERT INTO organizations (id, name)
+VALUES ('9d3e8a52-6c1f-4b7e-a0d4-2f5c8e1b7a90', 'Default organization')
+ON CONFLICT (id) DO NOTHING;
+INSERT INTO organization_members (organization_id, user_id, role)
+SELECT '9d3e8a52-6c1f-4b7e-a0d4-2f5c8e1b7a90', id, role
+FROM users
+ON CONFLICT (organization_id, user_id) DO NOTHING;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_check;
+ALTER TABLE users DROP COLUMN IF EXISTS role;
+-- customers
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS organization_id UUID REFERENCES organizations(id) ON DELETE CASCADE;
+UPDATE customers SET organization_id = '9d3e8a52-6c1f-4b7e-a0d4-2f5c8e1b7a90' WHERE organization_id IS NULL;
+ALTER TABLE customers ALTER COLUMN organization_id SET NOT NULL;
+ALTER TABLE customers DROP CONSTRAINT IF EXISTS customers_id_organization_id_key;
+ALTER TABLE customers ADD CONSTRAINT customers_id_organization_id_key UNIQUE (id, organization_id);
+CREATE INDEX IF NOT EXISTS customers_organization_id_idx ON customers (organization_id);
+-- invoices
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS organization_id UUID REFERENCES organizations(id) ON DELETE CASCADE;
+UPDATE invoices SET organization_id = '9d3e8a52-6c1f-4b7e-a0d4-2f5c8e1b7a90' WHERE organization_id IS NULL;
+ALTER TABLE invoices ALTER COLUMN organization_id SET NOT NULL;
+CREATE INDEX IF NOT EXISTS invoices_organization_id_idx ON invoices (organization_id);
+-- 別の組織の顧客を請求先にできないよう、外部キーに組織を含める
+ALTER TABLE invoices DROP CONSTRAINT IF EXISTS fk_customer;
+ALTER TABLE invoices
+ADD CONSTRAINT fk_customer
+FOREIGN KEY (customer_id, organization_id)
+REFERENCES customers(id, organization_id);
+-- revenue
+ALTER TABLE revenue ADD COLUMN IF NOT EXISTS organization_id UUID REFERENCES organizations(id) ON DELETE CASCADE;
+UPDATE revenue SET organization_id = '9d3e8a52-6c1f-4b7e-a0d4-2f5c8e1b7a90' WHERE organization_id IS NULL;
+ALTER TABLE revenue ALTER COLUMN organization_id SET NOT NULL;
+ALTER TABLE revenue DROP CONSTRAINT IF EXISTS revenue_month_key;
+ALTER TABLE revenue DROP CONSTRAINT IF EXISTS revenue_organization_id_month_key;
+ALTER TABLE revenue ADD CONSTRAINT revenue_organization_id_month_key UNIQUE (organization_id, month);
+-- refresh_tokens
+ALTER TABLE refresh_tokens ADD COLUMN IF NOT EXISTS organization_id UUID REFERENCES organizations(id) ON DELETE CASCADE;
+UPDATE refresh_tokens SET organization_id = '9d3e8a52-6c1f-4b7e-a0d4-2f5c8e1b7a90' WHERE organization_id IS NULL;
+ALTER TABLE refresh_tokens ALTER COLUMN organization_id SET NOT NULL;
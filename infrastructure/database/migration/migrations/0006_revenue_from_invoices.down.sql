@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS invoices_organization_id_status_date_idx;
+CREATE TABLE IF NOT EXISTS revenue (
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    month VARCHAR(4) NOT NULL,
+    revenue INT NOT NULL,
+    CONSTRAINT revenue_organization_id_month_key UNIQUE (organization_id, month)
+);
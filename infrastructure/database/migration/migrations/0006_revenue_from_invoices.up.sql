@@ -0,0 +1,3 @@
+-- 売上は支払い済みの請求書から集計するため、手入力の revenue テーブルは廃止する
+DROP TABLE IF EXISTS revenue;
+CREATE INDEX IF NOT EXISTS invoices_organization_id_status_date_idx ON invoices (organization_id, status, date);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS invoices_organization_id_date_id_idx;
@@ -0,0 +1,2 @@
+-- 請求書一覧のキーセットページネーション (date DESC, id DESC) 用
+CREATE INDEX IF NOT EXISTS invoices_organization_id_date_id_idx ON invoices (organization_id, date DESC, id DESC);
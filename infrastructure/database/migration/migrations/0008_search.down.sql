@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS invoice_items_description_trgm_idx;
+DROP INDEX IF EXISTS invoice_items_search_vector_idx;
+ALTER TABLE invoice_items DROP COLUMN IF EXISTS search_vector;
+DROP INDEX IF EXISTS customers_email_trgm_idx;
+DROP INDEX IF EXISTS customers_name_trgm_idx;
+DROP INDEX IF EXISTS customers_search_vector_idx;
+ALTER TABLE customers DROP COLUMN IF EXISTS search_vector;
+-- pg_trgm は他で使われている可能性があるため残す
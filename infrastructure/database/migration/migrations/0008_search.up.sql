@@ -0,0 +1,14 @@
+-- 顧客と請求書明細の全文検索・あいまい検索用
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+-- 名前やメールは言語に依存しないよう simple 設定で分かち書きする
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS search_vector TSVECTOR GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', name), 'A') || setweight(to_tsvector('simple', email), 'B')
+) STORED;
+CREATE INDEX IF NOT EXISTS customers_search_vector_idx ON customers USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS customers_name_trgm_idx ON customers USING GIN (name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS customers_email_trgm_idx ON customers USING GIN (email gin_trgm_ops);
+ALTER TABLE invoice_items ADD COLUMN IF NOT EXISTS search_vector TSVECTOR GENERATED ALWAYS AS (
+    to_tsvector('simple', description)
+) STORED;
+CREATE INDEX IF NOT EXISTS invoice_items_search_vector_idx ON invoice_items USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS invoice_items_description_trgm_idx ON invoice_items USING GIN (description gin_trgm_ops);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS invoice_status_history;
+ALTER TABLE invoices DROP CONSTRAINT IF EXISTS invoices_status_check;
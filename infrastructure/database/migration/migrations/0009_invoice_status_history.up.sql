@@ -0,0 +1,21 @@
+-- 請求書の状態を状態遷移で扱う値に限定する
+ALTER TABLE invoices DROP CONSTRAINT IF EXISTS invoices_status_check;
+ALTER TABLE invoices
+ADD CONSTRAINT invoices_status_check
+CHECK (status IN ('draft', 'sent', 'pending', 'partially_paid', 'paid', 'overdue', 'void'));
+CREATE TABLE IF NOT EXISTS invoice_status_history (
+    id UUID DEFAULT uuid_generate_v4() PRIMARY KEY,
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    invoice_id UUID NOT NULL REFERENCES invoices(id) ON DELETE CASCADE,
+    from_status VARCHAR(20),
+    to_status VARCHAR(20) NOT NULL,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS invoice_status_history_invoice_id_idx ON invoice_status_history (invoice_id, changed_at);
+-- 既存の請求書は発行日に現在の状態で作成されたものとして記録する
+INSERT INTO invoice_status_history (organization_id, invoice_id, to_status, changed_at)
+SELECT organization_id, id, status, date
+FROM invoices
+WHERE NOT EXISTS (
+        SELECT 1 FROM invoice_status_history WHERE invoice_status_history.invoice_id = invoices.id
+    );
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS invoices_status_due_date_idx;
+ALTER TABLE invoices DROP COLUMN IF EXISTS due_date;
+ALTER TABLE invoices DROP CONSTRAINT IF EXISTS invoices_payment_terms_check;
+ALTER TABLE invoices DROP COLUMN IF EXISTS payment_terms;
+ALTER TABLE customers DROP CONSTRAINT IF EXISTS customers_payment_terms_check;
+ALTER TABLE customers DROP COLUMN IF EXISTS payment_terms;
@@ -0,0 +1,17 @@
+-- 顧客ごとの既定の支払条件
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS payment_terms VARCHAR(20) NOT NULL DEFAULT 'net_30';
+ALTER TABLE customers DROP CONSTRAINT IF EXISTS customers_payment_terms_check;
+ALTER TABLE customers
+ADD CONSTRAINT customers_payment_terms_check
+CHECK (payment_terms IN ('net_15', 'net_30', 'net_60'));
+-- 請求書の支払条件と支払期日。custom は期日を直接指定したもの
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS payment_terms VARCHAR(20) NOT NULL DEFAULT 'net_30';
+ALTER TABLE invoices DROP CONSTRAINT IF EXISTS invoices_payment_terms_check;
+ALTER TABLE invoices
+ADD CONSTRAINT invoices_payment_terms_check
+CHECK (payment_terms IN ('net_15', 'net_30', 'net_60', 'custom'));
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS due_date DATE;
+UPDATE invoices SET due_date = date + 30 WHERE due_date IS NULL;
+ALTER TABLE invoices ALTER COLUMN due_date SET NOT NULL;
+-- 期日を過ぎた未払いの請求書を定期的に探すため
+CREATE INDEX IF NOT EXISTS invoices_status_due_date_idx ON invoices (status, due_date);
@@ -0,0 +1,21 @@
+-- 請求書ごとの入金記録
+CREATE TABLE IF NOT EXISTS payments (
+    id UUID DEFAULT uuid_generate_v4() PRIMARY KEY,
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    invoice_id UUID NOT NULL REFERENCES invoices(id),
+    amount INT NOT NULL CHECK (amount > 0),
+    method VARCHAR(20) NOT NULL CHECK (method IN ('bank_transfer', 'card', 'cash', 'check', 'other')),
+    reference VARCHAR(255) NOT NULL DEFAULT '',
+    received_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS payments_invoice_id_idx ON payments (invoice_id);
+-- 支払済みの既存の請求書は、発行日に全額を受け取ったものとして記録する
+INSERT INTO payments (organization_id, invoice_id, amount, method, reference, received_at)
+SELECT organization_id, id, amount, 'other', 'migrated', date
+FROM invoices
+WHERE status = 'paid'
+    AND amount > 0
+    AND NOT EXISTS (
+        SELECT 1 FROM payments WHERE payments.invoice_id = invoices.id
+    );
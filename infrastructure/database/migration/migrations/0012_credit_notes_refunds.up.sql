@@ -0,0 +1,31 @@
+-- 組織ごとの連番。採番は行ロックで直列化されるため欠番も重複も出ない
+CREATE TABLE IF NOT EXISTS number_sequences (
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    name VARCHAR(50) NOT NULL,
+    last_value BIGINT NOT NULL,
+    PRIMARY KEY (organization_id, name)
+);
+-- 請求書の金額を減額する貸方票。請求書自体は書き換えずに記録を残す
+CREATE TABLE IF NOT EXISTS credit_notes (
+    id UUID DEFAULT uuid_generate_v4() PRIMARY KEY,
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    invoice_id UUID NOT NULL REFERENCES invoices(id),
+    number VARCHAR(50) NOT NULL,
+    amount INT NOT NULL CHECK (amount > 0),
+    reason VARCHAR(255) NOT NULL,
+    issued_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (organization_id, number)
+);
+CREATE INDEX IF NOT EXISTS credit_notes_invoice_id_idx ON credit_notes (invoice_id);
+-- 入金ごとの返金記録
+CREATE TABLE IF NOT EXISTS refunds (
+    id UUID DEFAULT uuid_generate_v4() PRIMARY KEY,
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    payment_id UUID NOT NULL REFERENCES payments(id),
+    amount INT NOT NULL CHECK (amount > 0),
+    reason VARCHAR(255) NOT NULL DEFAULT '',
+    refunded_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS refunds_payment_id_idx ON refunds (payment_id);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS invoices_recurrence_idx;
+ALTER TABLE invoices DROP COLUMN IF EXISTS recurrence_date;
+ALTER TABLE invoices DROP COLUMN IF EXISTS recurring_invoice_id;
+DROP TABLE IF EXISTS recurring_invoices;
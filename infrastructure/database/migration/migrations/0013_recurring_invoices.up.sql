@@ -0,0 +1,21 @@
+-- 定期請求のひな形。next_date が来るたびに請求書を作り、次の請求日へ進める
+CREATE TABLE IF NOT EXISTS recurring_invoices (
+    id UUID DEFAULT uuid_generate_v4() PRIMARY KEY,
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    customer_id UUID NOT NULL REFERENCES customers(id),
+    description VARCHAR(255) NOT NULL,
+    amount INT NOT NULL CHECK (amount > 0),
+    tax_rate NUMERIC(6, 3) NOT NULL DEFAULT 0 CHECK (tax_rate >= 0 AND tax_rate <= 100),
+    frequency VARCHAR(20) NOT NULL CHECK (frequency IN ('monthly', 'quarterly', 'yearly')),
+    day_of_month INT NOT NULL CHECK (day_of_month BETWEEN 1 AND 31),
+    start_date DATE NOT NULL,
+    end_date DATE CHECK (end_date >= start_date),
+    next_date DATE,
+    last_invoice_date DATE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS recurring_invoices_next_date_idx ON recurring_invoices (next_date);
+-- 同じひな形の同じ請求日の請求書は一件だけにし、再起動や同時実行でも二重に請求しない
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS recurring_invoice_id UUID REFERENCES recurring_invoices(id) ON DELETE SET NULL;
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS recurrence_date DATE;
+CREATE UNIQUE INDEX IF NOT EXISTS invoices_recurrence_idx ON invoices (recurring_invoice_id, recurrence_date);
@@ -0,0 +1,3 @@
+DELETE FROM number_sequences WHERE name = 'invoice' OR name LIKE 'invoice:%';
+DROP INDEX IF EXISTS invoices_number_idx;
+ALTER TABLE invoices DROP COLUMN IF EXISTS number;
@@ -0,0 +1,31 @@
+-- 人が読める請求書番号。組織ごとに number_sequences から発行日の年の連番を振る
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS number VARCHAR(50);
+-- 既存の請求書には既定の形式 (INV-YYYY-00001) で発行日順に番号を振る
+UPDATE invoices
+SET number = numbered.number
+FROM (
+        SELECT id,
+            'INV-' || to_char(date, 'YYYY') || '-' || lpad(seq::text, greatest(5, length(seq::text)), '0') AS number
+        FROM (
+                SELECT id, date,
+                    row_number() OVER (PARTITION BY organization_id, date_part('year', date) ORDER BY date, id) AS seq
+                FROM invoices
+            ) AS ranked
+    ) AS numbered
+WHERE invoices.id = numbered.id
+    AND invoices.number IS NULL;
+-- 採番が振り済みの番号の続きから始まるよう、年ごとと通しの両方の連番を進めておく
+INSERT INTO number_sequences (organization_id, name, last_value)
+SELECT organization_id, 'invoice:' || to_char(date, 'YYYY'), count(*)
+FROM invoices
+GROUP BY organization_id, to_char(date, 'YYYY')
+ON CONFLICT (organization_id, name) DO UPDATE
+SET last_value = greatest(number_sequences.last_value, excluded.last_value);
+INSERT INTO number_sequences (organization_id, name, last_value)
+SELECT organization_id, 'invoice', count(*)
+FROM invoices
+GROUP BY organization_id
+ON CONFLICT (organization_id, name) DO UPDATE
+SET last_value = greatest(number_sequences.last_value, excluded.last_value);
+ALTER TABLE invoices ALTER COLUMN number SET NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS invoices_number_idx ON invoices (organization_id, number);
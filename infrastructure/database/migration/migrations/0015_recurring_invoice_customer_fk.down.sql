@@ -0,0 +1,5 @@
+ALTER TABLE recurring_invoices DROP CONSTRAINT IF EXISTS fk_recurring_invoice_customer;
+ALTER TABLE recurring_invoices
+ADD CONSTRAINT recurring_invoices_customer_id_fkey
+FOREIGN KEY (customer_id)
+REFERENCES customers(id);
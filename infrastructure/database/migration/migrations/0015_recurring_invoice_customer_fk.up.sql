@@ -0,0 +1,7 @@
+-- 請求書と同じく、別の組織の顧客をひな形の請求先にできないよう外部キーに組織を含める
+ALTER TABLE recurring_invoices DROP CONSTRAINT IF EXISTS recurring_invoices_customer_id_fkey;
+ALTER TABLE recurring_invoices DROP CONSTRAINT IF EXISTS fk_recurring_invoice_customer;
+ALTER TABLE recurring_invoices
+ADD CONSTRAINT fk_recurring_invoice_customer
+FOREIGN KEY (customer_id, organization_id)
+REFERENCES customers(id, organization_id);
@@ -0,0 +1,258 @@
+INSERT INTO users (id, name, email, password)
+VALUES (
+        '410544b2-4001-4271-9855-fec4b6a6442a',
+        'User',
+        'user@nextmail.com',
+        '123456'
+    );
+INSERT INTO organizations (id, name)
+VALUES ('9d3e8a52-6c1f-4b7e-a0d4-2f5c8e1b7a90', 'Default organization')
+ON CONFLICT (id) DO NOTHING;
+INSERT INTO organization_members (organization_id, user_id, role)
+VALUES (
+        '9d3e8a52-6c1f-4b7e-a0d4-2f5c8e1b7a90',
+        '410544b2-4001-4271-9855-fec4b6a6442a',
+        'admin'
+    );
+INSERT INTO customers (organization_id, id, name, email, image_url)
+VALUES (
+        '9d3e8a52-6c1f-4b7e-a0d4-2f5c8e1b7a90',
+        '3958dc9e-712f-4377-85e9-fec4b6a6442a',
+        'Delba de Oliveira',
+        'delba@oliveira.com',
+        '/customers/delba-de-oliveira.png'
+    ),
+    (
+        '9d3e8a52-6c1f-4b7e-a0d4-2f5c8e1b7a90',
+        '3958dc9e-742f-4377-85e9-fec4b6a6442a',
+        'Lee Robinson',
+        'lee@robinson.com',
+        '/customers/lee-robinson.png'
+    ),
+    (
+        '9d3e8a52-6c1f-4b7e-a0d4-2f5c8e1b7a90',
+        '3958dc9e-737f-4377-85e9-fec4b6a6442a',
+        'Hector Simpson',
+        'hector@simpson.com',
+        '/customers/hector-simpson.png'
+    ),
+    (
+        '9d3e8a52-6c1f-4b7e-a0d4-2f5c8e1b7a90',
+        '50ca3e18-62cd-11ee-8c99-0242ac120002',
+        'Steven Tey',
+        'steven@tey.com',
+        '/customers/steven-tey.png'
+    ),
+    (
+        '9d3e8a52-6c1f-4b7e-a0d4-2f5c8e1b7a90',
+        '3958dc9e-787f-4377-85e9-fec4b6a6442a',
+        'Steph Dietz',
+        'steph@dietz.com',
+        '/customers/steph-dietz.png'
+    ),
+    (
+        '9d3e8a52-6c1f-4b7e-a0d4-2f5c8e1b7a90',
+        '76d65c26-f784-44a2-ac19-586678f7c2f2',
+        'Michael Novotny',
+        'michael@novotny.com',
+        '/customers/michael-novotny.png'
+    ),
+    (
+        '9d3e8a52-6c1f-4b7e-a0d4-2f5c8e1b7a90',
+        'd6e15727-9fe1-4961-8c5b-ea44a9bd81aa',
+        'Evil Rabbit',
+        'evil@rabbit.com',
+        '/customers/evil-rabbit.png'
+    ),
+    (
+        '9d3e8a52-6c1f-4b7e-a0d4-2f5c8e1b7a90',
+        '126eed9c-c90c-4ef6-a4a8-fcf7408d3c66',
+        'Emil Kowalski',
+        'emil@kowalski.com',
+        '/customers/emil-kowalski.png'
+    ),
+    (
+        '9d3e8a52-6c1f-4b7e-a0d4-2f5c8e1b7a90',
+        'CC27C14A-0ACF-4F4A-A6C9-D45682C144B9',
+        'Amy Burns',
+        'amy@burns.com',
+        '/customers/amy-burns.png'
+    ),
+    (
+        '9d3e8a52-6c1f-4b7e-a0d4-2f5c8e1b7a90',
+        '13D07535-C59E-4157-A011-F8D2EF4E0CBB',
+        'Balazs Orban',
+        'balazs@orban.com',
+        '/customers/balazs-orban.png'
+    );
+INSERT INTO invoices (organization_id, number, customer_id, amount, status, date, due_date)
+VALUES (
+        '9d3e8a52-6c1f-4b7e-a0d4-2f5c8e1b7a90',
+        'INV-2022-00004',
+        '3958dc9e-712f-4377-85e9-fec4b6a6442a',
+        15795,
+        'pending',
+        '2022-12-06',
+        DATE '2022-12-06' + 30
+    ),
+    (
+        '9d3e8a52-6c1f-4b7e-a0d4-2f5c8e1b7a90',
+        'INV-2022-00003',
+        '3958dc9e-742f-4377-85e9-fec4b6a6442a',
+        20348,
+        'pending',
+        '2022-11-14',
+        DATE '2022-11-14' + 30
+    ),
+    (
+        '9d3e8a52-6c1f-4b7e-a0d4-2f5c8e1b7a90',
+        'INV-2022-00002',
+        '3958dc9e-787f-4377-85e9-fec4b6a6442a',
+        3040,
+        'paid',
+        '2022-10-29',
+        DATE '2022-10-29' + 30
+    ),
+    (
+        '9d3e8a52-6c1f-4b7e-a0d4-2f5c8e1b7a90',
+        'INV-2023-00010',
+        '50ca3e18-62cd-11ee-8c99-0242ac120002',
+        44800,
+        'paid',
+        '2023-09-10',
+        DATE '2023-09-10' + 30
+    ),
+    (
+        '9d3e8a52-6c1f-4b7e-a0d4-2f5c8e1b7a90',
+        'INV-2023-00008',
+        '76d65c26-f784-44a2-ac19-586678f7c2f2',
+        34577,
+        'pending',
+        '2023-08-05',
+        DATE '2023-08-05' + 30
+    ),
+    (
+        '9d3e8a52-6c1f-4b7e-a0d4-2f5c8e1b7a90',
+        'INV-2023-00007',
+        '126eed9c-c90c-4ef6-a4a8-fcf7408d3c66',
+        54246,
+        'pending',
+        '2023-07-16',
+        DATE '2023-07-16' + 30
+    ),
+    (
+        '9d3e8a52-6c1f-4b7e-a0d4-2f5c8e1b7a90',
+        'INV-2023-00006',
+        'd6e15727-9fe1-4961-8c5b-ea44a9bd81aa',
+        666,
+        'pending',
+        '2023-06-27',
+        DATE '2023-06-27' + 30
+    ),
+    (
+        '9d3e8a52-6c1f-4b7e-a0d4-2f5c8e1b7a90',
+        'INV-2023-00003',
+        '50ca3e18-62cd-11ee-8c99-0242ac120002',
+        32545,
+        'paid',
+        '2023-06-09',
+        DATE '2023-06-09' + 30
+    ),
+    (
+        '9d3e8a52-6c1f-4b7e-a0d4-2f5c8e1b7a90',
+        'INV-2023-00004',
+        '3958dc9e-787f-4377-85e9-fec4b6a6442a',
+        1250,
+        'paid',
+        '2023-06-17',
+        DATE '2023-06-17' + 30
+    ),
+    (
+        '9d3e8a52-6c1f-4b7e-a0d4-2f5c8e1b7a90',
+        'INV-2023-00002',
+        '76d65c26-f784-44a2-ac19-586678f7c2f2',
+        8546,
+        'paid',
+        '2023-06-07',
+        DATE '2023-06-07' + 30
+    ),
+    (
+        '9d3e8a52-6c1f-4b7e-a0d4-2f5c8e1b7a90',
+        'INV-2023-00009',
+        '3958dc9e-742f-4377-85e9-fec4b6a6442a',
+        500,
+        'paid',
+        '2023-08-19',
+        DATE '2023-08-19' + 30
+    ),
+    (
+        '9d3e8a52-6c1f-4b7e-a0d4-2f5c8e1b7a90',
+        'INV-2023-00001',
+        '76d65c26-f784-44a2-ac19-586678f7c2f2',
+        8945,
+        'paid',
+        '2023-06-03',
+        DATE '2023-06-03' + 30
+    ),
+    (
+        '9d3e8a52-6c1f-4b7e-a0d4-2f5c8e1b7a90',
+        'INV-2023-00005',
+        '3958dc9e-737f-4377-85e9-fec4b6a6442a',
+        8945,
+        'paid',
+        '2023-06-18',
+        DATE '2023-06-18' + 30
+    ),
+    (
+        '9d3e8a52-6c1f-4b7e-a0d4-2f5c8e1b7a90',
+        'INV-2023-00011',
+        '3958dc9e-712f-4377-85e9-fec4b6a6442a',
+        8945,
+        'paid',
+        '2023-10-04',
+        DATE '2023-10-04' + 30
+    ),
+    (
+        '9d3e8a52-6c1f-4b7e-a0d4-2f5c8e1b7a90',
+        'INV-2022-00001',
+        '3958dc9e-737f-4377-85e9-fec4b6a6442a',
+        1000,
+        'paid',
+        '2022-06-05',
+        DATE '2022-06-05' + 30
+    );
+-- デモ用の請求書は金額全体を 1 行の明細として登録する
+UPDATE invoices SET subtotal = amount WHERE subtotal = 0 AND tax = 0;
+INSERT INTO invoice_items (invoice_id, description, quantity, unit_price, subtotal, tax, total)
+SELECT id, 'Services', 1, amount, amount, 0, amount
+FROM invoices
+WHERE NOT EXISTS (
+        SELECT 1 FROM invoice_items WHERE invoice_items.invoice_id = invoices.id
+    );
+INSERT INTO invoice_status_history (organization_id, invoice_id, to_status, changed_at)
+SELECT organization_id, id, status, date
+FROM invoices
+WHERE NOT EXISTS (
+        SELECT 1 FROM invoice_status_history WHERE invoice_status_history.invoice_id = invoices.id
+    );
+INSERT INTO payments (organization_id, invoice_id, amount, method, reference, received_at)
+SELECT organization_id, id, amount, 'bank_transfer', '', date
+FROM invoices
+WHERE status = 'paid'
+    AND amount > 0
+    AND NOT EXISTS (
+        SELECT 1 FROM payments WHERE payments.invoice_id = invoices.id
+    );
+-- デモ用の請求書に振った番号の続きから採番する
+INSERT INTO number_sequences (organization_id, name, last_value)
+SELECT organization_id, 'invoice:' || to_char(date, 'YYYY'), count(*)
+FROM invoices
+GROUP BY organization_id, to_char(date, 'YYYY')
+ON CONFLICT (organization_id, name) DO UPDATE
+SET last_value = greatest(number_sequences.last_value, excluded.last_value);
+INSERT INTO number_sequences (organization_id, name, last_value)
+SELECT organization_id, 'invoice', count(*)
+FROM invoices
+GROUP BY organization_id
+ON CONFLICT (organization_id, name) DO UPDATE
+SET last_value = greatest(number_sequences.last_value, excluded.last_value);
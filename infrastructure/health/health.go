@@ -0,0 +1,202 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"next-learn-go/infrastructure/database/migration"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// CheckFunc reports a dependency as unhealthy by returning an error. It must
+// honour ctx, which carries the check timeout. Cached checks are not cancelled
+// when the probe that triggered them goes away, since other probes reuse the
+// result.
+type CheckFunc func(ctx context.Context) error
+
+type Result struct {
+	Name      string    `json:"name"`
+	Status    string    `json:"status"`
+	LatencyMs float64   `json:"latency_ms"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+	Cached    bool      `json:"cached"`
+}
+
+type Report struct {
+	Status string   `json:"status"`
+	Checks []Result `json:"checks"`
+}
+
+type Option func(c *check)
+
+// Uncached makes a check run on every probe, for checks that are cheap and
+// must reflect changes immediately.
+func Uncached() Option {
+	return func(c *check) {
+		c.cacheTTL = 0
+	}
+}
+
+// Registry holds the checks behind the liveness and readiness probes. Results
+// are cached so that frequent probes do not put load on the dependencies.
+type Registry interface {
+	AddLivenessCheck(name string, fn CheckFunc, opts ...Option)
+	AddReadinessCheck(name string, fn CheckFunc, opts ...Option)
+	Live(ctx context.Context) Report
+	Ready(ctx context.Context) Report
+}
+
+type check struct {
+	name     string
+	fn       CheckFunc
+	timeout  time.Duration
+	cacheTTL time.Duration
+
+	mu     sync.Mutex
+	result Result
+}
+
+type registry struct {
+	timeout   time.Duration
+	cacheTTL  time.Duration
+	liveness  []*check
+	readiness []*check
+}
+
+func NewRegistry(timeout, cacheTTL time.Duration) Registry {
+	return &registry{timeout: timeout, cacheTTL: cacheTTL}
+}
+
+// NewRegistryFromEnv reads HEALTH_CHECK_TIMEOUT (default 2s) and
+// HEALTH_CHECK_CACHE_TTL (default 5s).
+func NewRegistryFromEnv() Registry {
+	return NewRegistry(
+		durationFromEnv("HEALTH_CHECK_TIMEOUT", 2*time.Second),
+		durationFromEnv("HEALTH_CHECK_CACHE_TTL", 5*time.Second),
+	)
+}
+
+func (r *registry) AddLivenessCheck(name string, fn CheckFunc, opts ...Option) {
+	r.liveness = append(r.liveness, r.newCheck(name, fn, opts))
+}
+
+func (r *registry) AddReadinessCheck(name string, fn CheckFunc, opts ...Option) {
+	r.readiness = append(r.readiness, r.newCheck(name, fn, opts))
+}
+
+func (r *registry) newCheck(name string, fn CheckFunc, opts []Option) *check {
+	c := &check{name: name, fn: fn, timeout: r.timeout, cacheTTL: r.cacheTTL}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+func (r *registry) Live(ctx context.Context) Report {
+	return run(ctx, r.liveness)
+}
+
+func (r *registry) Ready(ctx context.Context) Report {
+	return run(ctx, r.readiness)
+}
+
+func run(ctx context.Context, checks []*check) Report {
+	report := Report{Status: StatusUp, Checks: make([]Result, len(checks))}
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c *check) {
+			defer wg.Done()
+			report.Checks[i] = c.run(ctx)
+		}(i, c)
+	}
+	wg.Wait()
+	for _, result := range report.Checks {
+		if result.Status != StatusUp {
+			report.Status = StatusDown
+		}
+	}
+	return report
+}
+
+func (c *check) run(ctx context.Context) Result {
+	// 同時に来たプローブは先に実行中のチェックの結果を待って使い回す
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cacheTTL > 0 && !c.result.CheckedAt.IsZero() && time.Since(c.result.CheckedAt) < c.cacheTTL {
+		result := c.result
+		result.Cached = true
+		return result
+	}
+
+	if c.cacheTTL > 0 {
+		// キャッシュした結果は他のプローブにも返すため、呼び出し元のプローブが
+		// 切断されてもチェック自体はタイムアウトまで続ける
+		ctx = context.WithoutCancel(ctx)
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	start := time.Now()
+	err := c.fn(ctx)
+	if err == nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = fmt.Errorf("timed out after %s", c.timeout)
+	}
+
+	result := Result{
+		Name:      c.name,
+		Status:    StatusUp,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+		CheckedAt: start,
+	}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+	c.result = result
+	return result
+}
+
+// DatabaseCheck pings the connection pool.
+func DatabaseCheck(db *bun.DB) CheckFunc {
+	return func(ctx context.Context) error {
+		return db.PingContext(ctx)
+	}
+}
+
+// MigrationCheck fails while the schema is behind the embedded migrations.
+func MigrationCheck(migrator migration.Migrator) CheckFunc {
+	return func(ctx context.Context) error {
+		pending, err := migrator.Pending(ctx)
+		if err != nil {
+			return err
+		}
+		if len(pending) > 0 {
+			return fmt.Errorf("%d pending migration(s), next is %04d_%s", len(pending), pending[0].Version, pending[0].Name)
+		}
+		return nil
+	}
+}
+
+func durationFromEnv(key string, fallback time.Duration) time.Duration {
+	if v := os.Getenv(key); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			return d
+		}
+		slog.Warn("invalid duration, using default", "key", key, "value", v)
+	}
+	return fallback
+}
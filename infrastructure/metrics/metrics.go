@@ -0,0 +1,131 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/uptrace/bun"
+)
+
+// 業務メトリクスの集計クエリがスクレイプを長時間止めないようにする
+const collectTimeout = 5 * time.Second
+
+// StatusCountFunc returns the number of records per status.
+type StatusCountFunc func(ctx context.Context) (map[string]int, error)
+
+// Metrics holds the collectors exposed on /metrics in the Prometheus text
+// format.
+type Metrics interface {
+	ObserveRequest(method, route string, status int, duration time.Duration)
+	QueryHook() bun.QueryHook
+	AddDBStats(db *sql.DB)
+	AddInvoiceStatusGauge(fn StatusCountFunc)
+	Handler() http.Handler
+}
+
+type metrics struct {
+	registry        *prometheus.Registry
+	requestDuration *prometheus.HistogramVec
+	queryDuration   *prometheus.HistogramVec
+}
+
+func NewMetrics() Metrics {
+	m := &metrics{
+		registry: prometheus.NewRegistry(),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "Duration of HTTP requests by route template, method and status code.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		queryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "db_query_duration_seconds",
+			Help:    "Duration of database queries by operation, table and outcome.",
+			Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+		}, []string{"operation", "table", "status"}),
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requestDuration,
+		m.queryDuration,
+	)
+	return m
+}
+
+func (m *metrics) ObserveRequest(method, route string, status int, duration time.Duration) {
+	m.requestDuration.WithLabelValues(method, route, strconv.Itoa(status)).Observe(duration.Seconds())
+}
+
+func (m *metrics) QueryHook() bun.QueryHook {
+	return &queryHook{m.queryDuration}
+}
+
+// AddDBStats exposes the connection pool statistics of db.
+func (m *metrics) AddDBStats(db *sql.DB) {
+	m.registry.MustRegister(collectors.NewDBStatsCollector(db, "postgres"))
+}
+
+// AddInvoiceStatusGauge exposes the number of invoices per status. fn runs on
+// every scrape.
+func (m *metrics) AddInvoiceStatusGauge(fn StatusCountFunc) {
+	m.registry.MustRegister(&statusCollector{
+		desc: prometheus.NewDesc("invoices", "Number of invoices by status across all organizations.", []string{"status"}, nil),
+		fn:   fn,
+	})
+}
+
+func (m *metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{ErrorHandling: promhttp.ContinueOnError})
+}
+
+type queryHook struct {
+	duration *prometheus.HistogramVec
+}
+
+func (h *queryHook) BeforeQuery(ctx context.Context, _ *bun.QueryEvent) context.Context {
+	return ctx
+}
+
+func (h *queryHook) AfterQuery(_ context.Context, event *bun.QueryEvent) {
+	table := ""
+	if event.IQuery != nil {
+		table = event.IQuery.GetTableName()
+	}
+	status := "ok"
+	if event.Err != nil && !errors.Is(event.Err, sql.ErrNoRows) {
+		status = "error"
+	}
+	h.duration.WithLabelValues(event.Operation(), table, status).Observe(time.Since(event.StartTime).Seconds())
+}
+
+type statusCollector struct {
+	desc *prometheus.Desc
+	fn   StatusCountFunc
+}
+
+func (c *statusCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *statusCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), collectTimeout)
+	defer cancel()
+	counts, err := c.fn(ctx)
+	if err != nil {
+		// 集計に失敗しても他のメトリクスは返す
+		slog.Error("collecting invoice metrics failed", "error", err)
+		ch <- prometheus.NewInvalidMetric(c.desc, err)
+		return
+	}
+	for status, count := range counts {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(count), status)
+	}
+}
@@ -0,0 +1,257 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"next-learn-go/entity"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// Branding is the company information printed on every invoice.
+type Branding struct {
+	Name           string
+	Address        string
+	Email          string
+	Phone          string
+	LogoPath       string
+	Footer         string
+	CurrencySymbol string
+}
+
+// BrandingFromEnv reads the COMPANY_* variables. Address and footer may span
+// several lines separated by "\n".
+func BrandingFromEnv() Branding {
+	b := Branding{
+		Name:           os.Getenv("COMPANY_NAME"),
+		Address:        strings.ReplaceAll(os.Getenv("COMPANY_ADDRESS"), `\n`, "\n"),
+		Email:          os.Getenv("COMPANY_EMAIL"),
+		Phone:          os.Getenv("COMPANY_PHONE"),
+		LogoPath:       os.Getenv("COMPANY_LOGO_PATH"),
+		Footer:         strings.ReplaceAll(os.Getenv("COMPANY_INVOICE_FOOTER"), `\n`, "\n"),
+		CurrencySymbol: os.Getenv("COMPANY_CURRENCY_SYMBOL"),
+	}
+	if b.CurrencySymbol == "" {
+		b.CurrencySymbol = "$"
+	}
+	return b
+}
+
+type InvoiceRenderer interface {
+	RenderInvoice(w io.Writer, invoice entity.Invoice) error
+}
+
+type invoiceRenderer struct {
+	branding Branding
+}
+
+func NewInvoiceRenderer(branding Branding) InvoiceRenderer {
+	return &invoiceRenderer{branding}
+}
+
+const (
+	pageMargin  = 15.0
+	lineHeight  = 6.0
+	accentRed   = 37
+	accentGreen = 99
+	accentBlue  = 235
+)
+
+// 明細表の列幅（A4 の本文幅 180mm）
+var itemColumns = []struct {
+	title string
+	width float64
+	align string
+}{
+	{"Description", 75, "L"},
+	{"Qty", 15, "R"},
+	{"Unit price", 30, "R"},
+	{"Tax", 30, "R"},
+	{"Total", 30, "R"},
+}
+
+func (r *invoiceRenderer) RenderInvoice(w io.Writer, invoice entity.Invoice) error {
+	doc := fpdf.New("P", "mm", "A4", "")
+	doc.SetMargins(pageMargin, pageMargin, pageMargin)
+	doc.SetAutoPageBreak(true, 25)
+	doc.SetTitle(fmt.Sprintf("Invoice %s", invoice.Number), true)
+	if r.branding.Name != "" {
+		doc.SetAuthor(r.branding.Name, true)
+	}
+	// 標準フォントは cp1252 のため、UTF-8 の文字列を変換してから書き込む
+	tr := doc.UnicodeTranslatorFromDescriptor("")
+	doc.SetFooterFunc(func() {
+		doc.SetY(-20)
+		doc.SetFont("Helvetica", "", 8)
+		doc.SetTextColor(120, 120, 120)
+		if r.branding.Footer != "" {
+			doc.MultiCell(0, 4, tr(r.branding.Footer), "", "C", false)
+		}
+		doc.CellFormat(0, 4, fmt.Sprintf("Page %d/{nb}", doc.PageNo()), "", 0, "C", false, 0, "")
+	})
+	doc.AliasNbPages("")
+	doc.AddPage()
+
+	r.renderHeader(doc, tr)
+	r.renderSummary(doc, tr, invoice)
+	r.renderItems(doc, tr, invoice.Items)
+	r.renderTotals(doc, tr, invoice)
+
+	if err := doc.Error(); err != nil {
+		return fmt.Errorf("render invoice pdf: %w", err)
+	}
+	return doc.Output(w)
+}
+
+func (r *invoiceRenderer) renderHeader(doc *fpdf.Fpdf, tr func(string) string) {
+	top := doc.GetY()
+	if r.branding.LogoPath != "" {
+		options := fpdf.ImageOptions{ImageType: strings.TrimPrefix(filepath.Ext(r.branding.LogoPath), "."), ReadDpi: true}
+		doc.ImageOptions(r.branding.LogoPath, pageMargin, top, 0, 18, false, options, 0, "")
+	}
+
+	// 会社情報は右寄せで表示する
+	doc.SetXY(pageMargin, top)
+	doc.SetFont("Helvetica", "B", 14)
+	doc.SetTextColor(0, 0, 0)
+	doc.CellFormat(0, 7, tr(r.branding.Name), "", 2, "R", false, 0, "")
+	doc.SetFont("Helvetica", "", 9)
+	doc.SetTextColor(90, 90, 90)
+	lines := []string{}
+	if r.branding.Address != "" {
+		lines = append(lines, strings.Split(r.branding.Address, "\n")...)
+	}
+	if r.branding.Email != "" {
+		lines = append(lines, r.branding.Email)
+	}
+	if r.branding.Phone != "" {
+		lines = append(lines, r.branding.Phone)
+	}
+	for _, line := range lines {
+		doc.CellFormat(0, 4.5, tr(line), "", 2, "R", false, 0, "")
+	}
+
+	if doc.GetY() < top+22 {
+		doc.SetY(top + 22)
+	}
+	doc.Ln(6)
+}
+
+func (r *invoiceRenderer) renderSummary(doc *fpdf.Fpdf, tr func(string) string, invoice entity.Invoice) {
+	doc.SetFont("Helvetica", "B", 22)
+	doc.SetTextColor(accentRed, accentGreen, accentBlue)
+	doc.CellFormat(0, 10, "INVOICE", "", 1, "L", false, 0, "")
+	doc.Ln(2)
+
+	top := doc.GetY()
+	doc.SetTextColor(0, 0, 0)
+	doc.SetFont("Helvetica", "B", 9)
+	doc.CellFormat(90, 5, "BILL TO", "", 2, "L", false, 0, "")
+	doc.SetFont("Helvetica", "", 10)
+	doc.CellFormat(90, lineHeight, tr(invoice.Customer.Name), "", 2, "L", false, 0, "")
+	doc.CellFormat(90, lineHeight, tr(invoice.Customer.Email), "", 2, "L", false, 0, "")
+	bottom := doc.GetY()
+
+	details := [][2]string{
+		{"Invoice", invoice.Number},
+		{"Date", invoice.Date.Format("January 2, 2006")},
+		{"Due", invoice.DueDate.Format("January 2, 2006")},
+		{"Status", strings.ToUpper(strings.ReplaceAll(invoice.Status, "_", " "))},
+	}
+	doc.SetY(top)
+	for _, d := range details {
+		doc.SetX(pageMargin + 90)
+		doc.SetFont("Helvetica", "B", 9)
+		doc.CellFormat(20, lineHeight, d[0], "", 0, "L", false, 0, "")
+		doc.SetFont("Helvetica", "", 9)
+		doc.CellFormat(70, lineHeight, d[1], "", 1, "R", false, 0, "")
+	}
+	if doc.GetY() < bottom {
+		doc.SetY(bottom)
+	}
+	doc.Ln(8)
+}
+
+func (r *invoiceRenderer) renderItems(doc *fpdf.Fpdf, tr func(string) string, items []entity.InvoiceItem) {
+	header := func() {
+		doc.SetFont("Helvetica", "B", 9)
+		doc.SetFillColor(accentRed, accentGreen, accentBlue)
+		doc.SetTextColor(255, 255, 255)
+		for _, col := range itemColumns {
+			doc.CellFormat(col.width, 8, col.title, "", 0, col.align, true, 0, "")
+		}
+		doc.Ln(-1)
+		doc.SetFont("Helvetica", "", 9)
+		doc.SetTextColor(0, 0, 0)
+	}
+	header()
+
+	_, pageHeight := doc.GetPageSize()
+	_, _, _, bottomMargin := doc.GetMargins()
+	for i, item := range items {
+		// SplitText は UTF-8 として解釈するため、変換後のバイト列は SplitLines で分割する
+		description := doc.SplitLines([]byte(tr(item.Description)), itemColumns[0].width-2)
+		height := float64(len(description)) * lineHeight
+		// 行の途中で改ページしないよう、収まらなければ次のページで見出しから書き直す
+		if doc.GetY()+height > pageHeight-bottomMargin {
+			doc.AddPage()
+			header()
+		}
+		fill := i%2 == 1
+		doc.SetFillColor(243, 244, 246)
+		x, y := doc.GetXY()
+		doc.MultiCell(itemColumns[0].width, lineHeight, string(bytes.Join(description, []byte("\n"))), "", "L", fill)
+		doc.SetXY(x+itemColumns[0].width, y)
+		values := []string{
+			fmt.Sprintf("%d", item.Quantity),
+			r.money(item.UnitPrice),
+			fmt.Sprintf("%s (%g%%)", r.money(item.Tax), item.TaxRate),
+			r.money(item.Total),
+		}
+		for j, v := range values {
+			col := itemColumns[j+1]
+			doc.CellFormat(col.width, height, tr(v), "", 0, col.align, fill, 0, "")
+		}
+		doc.SetXY(pageMargin, y+height)
+	}
+	doc.Ln(4)
+}
+
+func (r *invoiceRenderer) renderTotals(doc *fpdf.Fpdf, tr func(string) string, invoice entity.Invoice) {
+	balance := entity.NewInvoiceBalance(invoice)
+	rows := [][2]string{
+		{"Subtotal", r.money(invoice.Subtotal)},
+		{"Tax", r.money(invoice.Tax)},
+		{"Total", r.money(invoice.Amount)},
+		{"Amount paid", r.money(balance.NetPaid())},
+		{"Amount credited", r.money(balance.Credited)},
+	}
+	doc.SetFont("Helvetica", "", 10)
+	for _, row := range rows {
+		doc.SetX(pageMargin + 110)
+		doc.CellFormat(40, lineHeight, row[0], "", 0, "L", false, 0, "")
+		doc.CellFormat(30, lineHeight, tr(row[1]), "", 1, "R", false, 0, "")
+	}
+	doc.SetX(pageMargin + 110)
+	doc.SetFont("Helvetica", "B", 11)
+	doc.CellFormat(40, 8, "Amount due", "T", 0, "L", false, 0, "")
+	doc.CellFormat(30, 8, tr(r.money(balance.Due(invoice.Amount))), "T", 1, "R", false, 0, "")
+}
+
+// money formats an amount in cents, e.g. 123456 -> "$1,234.56".
+func (r *invoiceRenderer) money(cents int) string {
+	sign := ""
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	whole := fmt.Sprintf("%d", cents/100)
+	for i := len(whole) - 3; i > 0; i -= 3 {
+		whole = whole[:i] + "," + whole[i:]
+	}
+	return fmt.Sprintf("%s%s%s.%02d", sign, r.branding.CurrencySymbol, whole, cents%100)
+}
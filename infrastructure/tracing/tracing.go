@@ -0,0 +1,101 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+)
+
+const (
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+
+	defaultServiceName = "next-learn-go"
+	// 終了時に残りのスパンを送り切るまでの猶予
+	flushTimeout = 5 * time.Second
+)
+
+// NewTracerProviderFromEnv installs the global tracer provider and the W3C
+// trace context propagator, and returns a closer that flushes pending spans.
+//
+// OTEL_TRACES_EXPORTER selects the exporter: "otlp" sends spans over OTLP/HTTP
+// to OTEL_EXPORTER_OTLP_ENDPOINT, "stdout" writes them as JSON to stdout or to
+// the file named by OTEL_TRACES_FILE, and "none" (the default) records
+// nothing. The service name is taken from OTEL_SERVICE_NAME.
+func NewTracerProviderFromEnv(ctx context.Context) (io.Closer, error) {
+	// 受け取った traceparent を引き継ぎ、下流にも伝播させる
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	exporterName := os.Getenv("OTEL_TRACES_EXPORTER")
+	if exporterName == "" {
+		exporterName = ExporterNone
+	}
+	if exporterName == ExporterNone {
+		return closerFunc(func() error { return nil }), nil
+	}
+
+	var file *os.File
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch exporterName {
+	case ExporterOTLP:
+		exporter, err = otlptracehttp.New(ctx)
+	case ExporterStdout:
+		out := io.Writer(os.Stdout)
+		if path := os.Getenv("OTEL_TRACES_FILE"); path != "" {
+			file, err = os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+			if err != nil {
+				return nil, fmt.Errorf("open OTEL_TRACES_FILE: %w", err)
+			}
+			out = file
+		}
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(out))
+	default:
+		return nil, fmt.Errorf("unknown OTEL_TRACES_EXPORTER %q", exporterName)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(
+		resource.NewSchemaless(semconv.ServiceName(defaultServiceName)),
+		resource.Environment(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(tp)
+
+	return closerFunc(func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+		defer cancel()
+		err := tp.Shutdown(ctx)
+		if file != nil {
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+		}
+		return err
+	}), nil
+}
+
+type closerFunc func() error
+
+func (f closerFunc) Close() error {
+	return f()
+}
@@ -0,0 +1,177 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"next-learn-go/logger"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Worker is a background task that runs until ctx is cancelled.
+type Worker func(ctx context.Context) error
+
+// Lifecycle runs the HTTP server and background workers until SIGINT or
+// SIGTERM, then shuts them down in order: readiness fails, in-flight
+// requests drain, workers stop and finally the registered closers run in
+// reverse order, so the database registered first is closed last.
+type Lifecycle interface {
+	Ready() bool
+	AddWorker(name string, worker Worker)
+	AddCloser(name string, closer io.Closer)
+	Run(ctx context.Context, e *echo.Echo, addr string) error
+}
+
+type namedWorker struct {
+	name   string
+	worker Worker
+}
+
+type namedCloser struct {
+	name   string
+	closer io.Closer
+}
+
+type lifecycle struct {
+	timeout time.Duration
+	ready   atomic.Bool
+	workers []namedWorker
+	closers []namedCloser
+}
+
+func NewLifecycle(timeout time.Duration) Lifecycle {
+	return &lifecycle{timeout: timeout}
+}
+
+// TimeoutFromEnv reads SHUTDOWN_TIMEOUT (e.g. "30s"), the time allowed for
+// draining requests and stopping workers.
+func TimeoutFromEnv() time.Duration {
+	return DurationFromEnv("SHUTDOWN_TIMEOUT", 30*time.Second)
+}
+
+// DurationFromEnv reads a positive duration such as "1h" from the environment
+// variable key, falling back to fallback when it is unset or invalid. Zero is
+// invalid here; settings where "0" turns a limit off, such as REQUEST_TIMEOUT,
+// are read by their own parsers (see middleware.TimeoutConfigFromEnv).
+func DurationFromEnv(key string, fallback time.Duration) time.Duration {
+	if v := os.Getenv(key); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			return d
+		}
+		slog.Warn("invalid "+key+", using default", "value", v)
+	}
+	return fallback
+}
+
+func (l *lifecycle) Ready() bool {
+	return l.ready.Load()
+}
+
+func (l *lifecycle) AddWorker(name string, worker Worker) {
+	l.workers = append(l.workers, namedWorker{name, worker})
+}
+
+func (l *lifecycle) AddCloser(name string, closer io.Closer) {
+	l.closers = append(l.closers, namedCloser{name, closer})
+}
+
+func (l *lifecycle) Run(ctx context.Context, e *echo.Echo, addr string) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	workerCtx, cancelWorkers := context.WithCancel(context.Background())
+	defer cancelWorkers()
+	var wg sync.WaitGroup
+	for _, w := range l.workers {
+		wg.Add(1)
+		go func(w namedWorker) {
+			defer wg.Done()
+			slog.Info("worker started", "worker", w.name)
+			// ワーカー内のログにもワーカー名を付ける
+			ctx := logger.WithContext(workerCtx, slog.Default().With("worker", w.name))
+			if err := w.worker(ctx); err != nil && !errors.Is(err, context.Canceled) {
+				slog.Error("worker failed", "worker", w.name, "error", err)
+				return
+			}
+			slog.Info("worker stopped", "worker", w.name)
+		}(w)
+	}
+
+	serverErr := make(chan error, 1)
+	go func() {
+		slog.Info("server listening", "addr", addr)
+		if err := e.Start(addr); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+		close(serverErr)
+	}()
+	l.ready.Store(true)
+
+	var runErr error
+	select {
+	case <-ctx.Done():
+		slog.Info("shutdown signal received")
+	case err := <-serverErr:
+		// サーバーが起動できなかった場合も同じ手順で後片付けする
+		runErr = err
+		slog.Error("server stopped unexpectedly", "error", err)
+	}
+	stop()
+
+	if err := l.shutdown(e, cancelWorkers, &wg); err != nil && runErr == nil {
+		runErr = err
+	}
+	return runErr
+}
+
+func (l *lifecycle) shutdown(e *echo.Echo, cancelWorkers context.CancelFunc, wg *sync.WaitGroup) error {
+	var errs []error
+	// 準備完了を先に落とし、ロードバランサーが新しいリクエストを送らないようにする
+	l.ready.Store(false)
+	slog.Info("draining HTTP connections", "timeout", l.timeout.String())
+
+	ctx, cancel := context.WithTimeout(context.Background(), l.timeout)
+	defer cancel()
+	if err := e.Shutdown(ctx); err != nil {
+		slog.Error("HTTP drain incomplete", "error", err)
+		errs = append(errs, err)
+	} else {
+		slog.Info("HTTP server stopped")
+	}
+
+	slog.Info("stopping workers", "count", len(l.workers))
+	cancelWorkers()
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		slog.Info("workers stopped")
+	case <-ctx.Done():
+		slog.Error("workers did not stop before the timeout")
+		errs = append(errs, errors.New("workers did not stop before the shutdown timeout"))
+	}
+
+	for i := len(l.closers) - 1; i >= 0; i-- {
+		c := l.closers[i]
+		if err := c.closer.Close(); err != nil {
+			slog.Error("close failed", "resource", c.name, "error", err)
+			errs = append(errs, err)
+			continue
+		}
+		slog.Info("closed", "resource", c.name)
+	}
+	slog.Info("shutdown complete")
+	return errors.Join(errs...)
+}
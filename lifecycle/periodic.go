@@ -0,0 +1,27 @@
+package lifecycle
+
+import (
+	"context"
+	"next-learn-go/logger"
+	"time"
+)
+
+// Periodic returns a worker that runs task once right away and then every
+// interval until it is stopped. A failed run is logged and the task is tried
+// again at the next tick.
+func Periodic(interval time.Duration, task func(ctx context.Context) error) Worker {
+	return func(ctx context.Context) error {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if err := task(ctx); err != nil && ctx.Err() == nil {
+				logger.FromContext(ctx).Error("scheduled run failed", "error", err)
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-ticker.C:
+			}
+		}
+	}
+}
@@ -1,22 +1,85 @@
 package main
 
 import (
+	"context"
+	"log/slog"
 	"next-learn-go/infrastructure/database"
+	"next-learn-go/infrastructure/database/migration"
+	"next-learn-go/infrastructure/tracing"
+	"next-learn-go/lifecycle"
+	"next-learn-go/logger"
+	"next-learn-go/repository"
 
 	"next-learn-go/router"
 
 	"os"
+
+	"github.com/joho/godotenv"
 )
 
 func main() {
+	if os.Getenv("GO_ENV") != "prod" {
+		// LOG_LEVEL も .env から読むため、ロガーより先に読み込む
+		if err := godotenv.Load(); err != nil {
+			exit(err)
+		}
+	}
+	slog.SetDefault(logger.New())
 
 	db := database.NewDB()
 
-	e := router.NewRouter(db)
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		err := runMigrate(context.Background(), db, os.Args[2:])
+		db.Close()
+		if err != nil {
+			exit(err)
+		}
+		return
+	}
+
+	// 番号は法的な意味を持つため、設定の誤りは既定の形式で続行せず起動を止める
+	invoiceNumbering, err := repository.InvoiceNumberingFromEnv()
+	if err != nil {
+		exit(err)
+	}
+
+	migrator, err := migration.NewMigrator(db)
+	if err != nil {
+		exit(err)
+	}
+	if os.Getenv("DB_AUTO_MIGRATE") != "false" {
+		if err := migrator.Up(context.Background()); err != nil {
+			exit(err)
+		}
+		if os.Getenv("DB_SEED") == "true" {
+			if err := migrator.Seed(context.Background()); err != nil {
+				exit(err)
+			}
+		}
+	}
+
+	tracerProvider, err := tracing.NewTracerProviderFromEnv(context.Background())
+	if err != nil {
+		exit(err)
+	}
+
+	lc := lifecycle.NewLifecycle(lifecycle.TimeoutFromEnv())
+	// 最初に登録したものが最後に閉じられるので、データベースを先に登録する
+	lc.AddCloser("database", db)
+	lc.AddCloser("tracing", tracerProvider)
+
+	e := router.NewRouter(db, lc, migrator, invoiceNumbering)
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
 	}
-	e.Logger.Fatal(e.Start(":" + port))
+	if err := lc.Run(context.Background(), e, ":"+port); err != nil {
+		exit(err)
+	}
+
+}
 
+func exit(err error) {
+	slog.Error(err.Error())
+	os.Exit(1)
 }
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"next-learn-go/infrastructure/database/migration"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+const migrateUsage = `usage: go run . migrate <command>
+
+commands:
+  status      show applied and pending migrations
+  up          apply all pending migrations
+  down [n]    roll back the last n migrations (default 1)
+  redo        roll back and re-apply the last migration
+  seed        load demo data`
+
+func runMigrate(ctx context.Context, db *bun.DB, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	migrator, err := migration.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+		for _, s := range statuses {
+			status, appliedAt := "pending", ""
+			if s.Applied {
+				status, appliedAt = "applied", s.AppliedAt.Format(time.RFC3339)
+			}
+			if s.Modified {
+				status += " (modified)"
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", s.Version, s.Name, status, appliedAt)
+		}
+		return w.Flush()
+	case "up":
+		return migrator.Up(ctx)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("invalid number of steps: %s", args[1])
+			}
+		}
+		return migrator.Down(ctx, steps)
+	case "redo":
+		return migrator.Redo(ctx)
+	case "seed":
+		return migrator.Seed(ctx)
+	default:
+		return errors.New(migrateUsage)
+	}
+}
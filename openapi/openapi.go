@@ -0,0 +1,95 @@
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+//go:embed openapi.json
+var spec []byte
+
+// Spec returns the OpenAPI document served at /openapi.json.
+func Spec() []byte {
+	return spec
+}
+
+// undocumented lists routes that are not API operations, such as the static
+// assets of the Swagger UI.
+var undocumented = map[string]bool{
+	"GET /docs/*": true,
+}
+
+// CheckRoutes reports routes registered on the router that have no operation
+// in the document, and operations that no route serves. The router tests run
+// it so the document cannot drift from the router.
+func CheckRoutes(routes []*echo.Route) error {
+	var doc struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(spec, &doc); err != nil {
+		return fmt.Errorf("openapi: parse document: %w", err)
+	}
+
+	documented := map[string]bool{}
+	for path, operations := range doc.Paths {
+		for method := range operations {
+			documented[strings.ToUpper(method)+" "+path] = true
+		}
+	}
+
+	registered := map[string]bool{}
+	missing := []string{}
+	for _, r := range routes {
+		// Group.Use が登録する 404 用のルートは API ではない
+		if r.Method == echo.RouteNotFound {
+			continue
+		}
+		key := r.Method + " " + r.Path
+		if undocumented[key] {
+			continue
+		}
+		key = r.Method + " " + toOpenAPIPath(r.Path)
+		registered[key] = true
+		if !documented[key] {
+			missing = append(missing, key)
+		}
+	}
+	stale := []string{}
+	for key := range documented {
+		if !registered[key] {
+			stale = append(stale, key)
+		}
+	}
+	if len(missing) == 0 && len(stale) == 0 {
+		return nil
+	}
+
+	sort.Strings(missing)
+	sort.Strings(stale)
+	var b strings.Builder
+	b.WriteString("openapi: document does not match the router")
+	if len(missing) > 0 {
+		b.WriteString("; routes without an operation: " + strings.Join(missing, ", "))
+	}
+	if len(stale) > 0 {
+		b.WriteString("; operations without a route: " + strings.Join(stale, ", "))
+	}
+	return fmt.Errorf("%s", b.String())
+}
+
+// toOpenAPIPath rewrites echo parameters such as ":invoiceId" to
+// "{invoiceId}".
+func toOpenAPIPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		if strings.HasPrefix(s, ":") {
+			segments[i] = "{" + s[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
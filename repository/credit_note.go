@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"next-learn-go/entity"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+const creditNoteSequence = "credit_note"
+
+type CreditNoteRepository interface {
+	GetCreditNotes(ctx context.Context, creditNotes *[]entity.CreditNote, invoiceId uuid.UUID) error
+	CreateCreditNote(ctx context.Context, creditNote *entity.CreditNote, settle SettleFunc) error
+}
+
+type creditNoteRepository struct {
+	db *bun.DB
+}
+
+func NewCreditNoteRepository(db *bun.DB) CreditNoteRepository {
+	return &creditNoteRepository{db}
+}
+
+func (cr *creditNoteRepository) GetCreditNotes(ctx context.Context, creditNotes *[]entity.CreditNote, invoiceId uuid.UUID) error {
+	if err := cr.db.NewSelect().
+		Model(creditNotes).
+		Where("cn.invoice_id=?", invoiceId).
+		Order("cn.issued_at ASC", "cn.created_at ASC").
+		Scan(ctx); err != nil {
+		return translateError(err, "credit note")
+	}
+	return nil
+}
+
+// CreateCreditNote numbers and records creditNote, and moves its invoice to
+// the status settle returns for the reduced balance.
+func (cr *creditNoteRepository) CreateCreditNote(ctx context.Context, creditNote *entity.CreditNote, settle SettleFunc) error {
+	return cr.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		invoice, balance, err := lockInvoiceBalance(ctx, tx, creditNote.InvoiceId)
+		if err != nil {
+			return err
+		}
+		balance.Credited += creditNote.Amount
+		status, err := settle(invoice, balance)
+		if err != nil {
+			return err
+		}
+		number, err := nextSequenceValue(ctx, tx, invoice.OrganizationId, creditNoteSequence)
+		if err != nil {
+			return err
+		}
+		creditNote.Number = fmt.Sprintf("CN-%05d", number)
+		if _, err := tx.NewInsert().Model(creditNote).Exec(ctx); err != nil {
+			return translateError(err, "credit note")
+		}
+		if status != invoice.Status {
+			return updateInvoiceStatus(ctx, tx, invoice.ID, invoice.Status, status)
+		}
+		return nil
+	})
+}
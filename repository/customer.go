@@ -2,8 +2,10 @@ package repository
 
 import (
 	"context"
+	"next-learn-go/apperror"
 	"next-learn-go/entity"
 
+	"github.com/google/uuid"
 	"github.com/uptrace/bun"
 )
 
@@ -11,6 +13,10 @@ type CustomerRepository interface {
 	GetAllCustomers(ctx context.Context, customers *[]entity.Customer) error
 	GetFilteredCustomers(ctx context.Context, customers *[]entity.Customer, filter string) error
 	GetCustomerCount(ctx context.Context) (int, error)
+	GetCustomerById(ctx context.Context, customer *entity.Customer, customerId uuid.UUID) error
+	CreateCustomer(ctx context.Context, customer *entity.Customer) error
+	UpdateCustomer(ctx context.Context, customer *entity.Customer, customerId uuid.UUID) error
+	DeleteCustomer(ctx context.Context, customerId uuid.UUID) error
 }
 
 type customerRepository struct {
@@ -25,35 +31,133 @@ func (cr *customerRepository) GetAllCustomers(ctx context.Context, customers *[]
 	if err := cr.db.NewSelect().
 		Model(customers).
 		Scan(ctx); err != nil {
-		return err
+		return translateError(err, "customer")
 	}
 	return nil
 }
 
+// invoicePaidTotals sums the payments and refunds of each invoice. Refunds are
+// summed per payment first so that a payment with several refunds is counted
+// once.
+const invoicePaidTotals = `SELECT p.invoice_id, SUM(p.amount) AS amount, SUM(COALESCE(rf.amount, 0)) AS refunded
+FROM payments AS p
+LEFT JOIN (SELECT payment_id, SUM(amount) AS amount FROM refunds GROUP BY payment_id) AS rf ON rf.payment_id = p.id
+GROUP BY p.invoice_id`
+
 func (cr *customerRepository) GetFilteredCustomers(ctx context.Context, customers *[]entity.Customer, filter string) error {
-	query := "%" + filter + "%"
-	if err := cr.db.NewSelect().
+	q := cr.db.NewSelect().
 		Model(customers).
 		Column("id", "name", "email", "image_url").
 		ColumnExpr("COUNT(invoices.id) AS total_invoices").
-		ColumnExpr("SUM(CASE WHEN invoices.status = 'pending' THEN invoices.amount ELSE 0 END) AS total_pending").
-		ColumnExpr("SUM(CASE WHEN invoices.status = 'paid' THEN invoices.amount ELSE 0 END) AS total_paid").
+		// 未払いは残高、支払済みは返金を差し引いた実際の入金額で集計する
+		ColumnExpr("SUM(CASE WHEN invoices.status IN (?) THEN invoices.amount - COALESCE(paid.amount, 0) + COALESCE(paid.refunded, 0) - COALESCE(credited.amount, 0) ELSE 0 END) AS total_pending", bun.In(entity.OutstandingInvoiceStatuses)).
+		ColumnExpr("SUM(COALESCE(paid.amount, 0) - COALESCE(paid.refunded, 0)) AS total_paid").
+		ColumnExpr("SUM(COALESCE(credited.amount, 0)) AS total_credited").
+		ColumnExpr("SUM(COALESCE(paid.refunded, 0)) AS total_refunded").
 		Join("LEFT JOIN invoices ON c.id = invoices.customer_id").
-		WhereGroup("AND", func(q *bun.SelectQuery) *bun.SelectQuery {
+		Join("LEFT JOIN (?) AS paid ON paid.invoice_id = invoices.id", bun.Safe(invoicePaidTotals)).
+		Join("LEFT JOIN (SELECT invoice_id, SUM(amount) AS amount FROM credit_notes GROUP BY invoice_id) AS credited ON credited.invoice_id = invoices.id").
+		Group("c.id", "c.name", "c.email", "c.image_url")
+	if filter == "" {
+		q = q.Order("c.name ASC")
+	} else {
+		// 部分一致はトライグラムインデックスで引き、綴り違いも拾って関連度順に並べる
+		query := "%" + filter + "%"
+		q = q.WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
 			return q.WhereOr("c.name ILIKE ?", query).
-				WhereOr("c.email ILIKE ?", query)
+				WhereOr("c.email ILIKE ?", query).
+				WhereOr(customerMatch, filter)
 		}).
-		Group("c.id", "c.name", "c.email", "c.image_url").
-		Order("c.name ASC").
-		Scan(ctx); err != nil {
-		return err
+			OrderExpr(customerScore+" DESC", filter).
+			Order("c.name ASC")
+	}
+	if err := q.Scan(ctx); err != nil {
+		return translateError(err, "customer")
 	}
 	return nil
 }
 func (cr *customerRepository) GetCustomerCount(ctx context.Context) (int, error) {
-	count, err := cr.db.NewSelect().Model((*entity.Customer)(nil)).Count(ctx)
+	count, err := scopedCount(ctx, cr.db.NewSelect().Model((*entity.Customer)(nil)))
 	if err != nil {
-		return 0, err
+		return 0, translateError(err, "customer")
 	}
 	return count, nil
 }
+
+func (cr *customerRepository) GetCustomerById(ctx context.Context, customer *entity.Customer, customerId uuid.UUID) error {
+	if err := cr.db.NewSelect().
+		Model(customer).
+		Where("c.id=?", customerId).
+		Scan(ctx); err != nil {
+		return translateError(err, "customer")
+	}
+	return nil
+}
+
+func (cr *customerRepository) CreateCustomer(ctx context.Context, customer *entity.Customer) error {
+	if _, err := cr.db.NewInsert().Model(customer).Exec(ctx); err != nil {
+		return translateError(err, "customer")
+	}
+	return nil
+}
+
+func (cr *customerRepository) UpdateCustomer(ctx context.Context, customer *entity.Customer, customerId uuid.UUID) error {
+	result, err := cr.db.NewUpdate().
+		Model(customer).
+		Column("name", "email", "image_url", "payment_terms").
+		Where("id=?", customerId).
+		Exec(ctx)
+	if err != nil {
+		return translateError(err, "customer")
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return translateError(err, "customer")
+	}
+	if rowsAffected < 1 {
+		return apperror.NotFound("customer not found")
+	}
+	return nil
+}
+
+func (cr *customerRepository) DeleteCustomer(ctx context.Context, customerId uuid.UUID) error {
+	return cr.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		// 行ロックで請求書の同時作成を防いでから請求書の有無を確認する
+		customer := entity.Customer{}
+		if err := tx.NewSelect().
+			Model(&customer).
+			Column("id").
+			Where("c.id=?", customerId).
+			For("UPDATE").
+			Scan(ctx); err != nil {
+			return translateError(err, "customer")
+		}
+
+		hasInvoices, err := scopedExists(ctx, tx.NewSelect().
+			Model((*entity.Invoice)(nil)).
+			Where("customer_id=?", customerId))
+		if err != nil {
+			return translateError(err, "customer")
+		}
+		if hasInvoices {
+			return apperror.Conflict("customer still has invoices")
+		}
+		hasRecurringInvoices, err := scopedExists(ctx, tx.NewSelect().
+			Model((*entity.RecurringInvoice)(nil)).
+			Where("customer_id=?", customerId))
+		if err != nil {
+			return translateError(err, "customer")
+		}
+		if hasRecurringInvoices {
+			return apperror.Conflict("customer still has recurring invoices")
+		}
+
+		if _, err := tx.NewDelete().
+			Model(&entity.Customer{}).
+			Where("id=?", customerId).
+			Exec(ctx); err != nil {
+			return translateError(err, "customer")
+		}
+		return nil
+	})
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"next-learn-go/apperror"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// translateError wraps database errors into apperror kinds so the HTTP layer
+// can answer with the right status code.
+func translateError(err error, resource string) error {
+	if err == nil {
+		return nil
+	}
+	var appErr *apperror.Error
+	if errors.As(err, &appErr) {
+		return err
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return apperror.Wrap(apperror.KindNotFound, err, resource+" not found")
+	}
+	// リクエストの期限切れや切断でクエリが取り消された場合
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return apperror.Wrap(apperror.KindTimeout, err, "request timed out")
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code.Name() {
+		case "unique_violation":
+			return apperror.Wrap(apperror.KindConflict, err, resource+" already exists")
+		case "foreign_key_violation":
+			if strings.HasPrefix(pqErr.Message, "insert or update") {
+				return apperror.Wrap(apperror.KindValidation, err, resource+" references a record that does not exist")
+			}
+			return apperror.Wrap(apperror.KindConflict, err, resource+" is still referenced by other records")
+		case "query_canceled":
+			return apperror.Wrap(apperror.KindTimeout, err, "request timed out")
+		case "check_violation", "not_null_violation", "invalid_text_representation", "string_data_right_truncation":
+			return apperror.Wrap(apperror.KindValidation, err, "invalid "+resource)
+		}
+	}
+	return err
+}
@@ -2,8 +2,10 @@ package repository
 
 import (
 	"context"
-	"fmt"
+	"database/sql"
+	"next-learn-go/apperror"
 	"next-learn-go/entity"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/uptrace/bun"
@@ -11,22 +13,28 @@ import (
 
 type InvoiceRepository interface {
 	GetLatestInvoices(ctx context.Context, invoices *[]entity.Invoice, offset, limit int) error
-	GetFilteredInvoices(ctx context.Context, invoices *[]entity.Invoice, query string, offset, limit int) error
+	GetFilteredInvoices(ctx context.Context, invoices *[]entity.Invoice, filter entity.InvoiceFilter, offset, limit int) error
+	GetLatestInvoicesPage(ctx context.Context, invoices *[]entity.Invoice, cursor entity.Cursor, limit int) (bool, error)
+	GetFilteredInvoicesPage(ctx context.Context, invoices *[]entity.Invoice, filter entity.InvoiceFilter, cursor entity.Cursor, limit int, withTotal bool) (bool, int, error)
 	GetInvoiceCount(ctx context.Context) (int, error)
-	GetInvoiceStatusCount(ctx context.Context) (int, int, error)
-	GetInvoicesPages(ctx context.Context, query string, offset, limit int) (int, error)
+	CountInvoicesByStatus(ctx context.Context) (map[string]int, error)
+	GetInvoicesPages(ctx context.Context, filter entity.InvoiceFilter, offset, limit int) (int, error)
 	GetInvoiceById(ctx context.Context, invoice *entity.Invoice, invoiceId uuid.UUID) error
+	GetInvoiceByNumber(ctx context.Context, invoice *entity.Invoice, number string) error
 	CreateInvoice(ctx context.Context, invoice *entity.Invoice) error
-	UpdateInvoice(ctx context.Context, invoice *entity.Invoice, invoiceId uuid.UUID) error
+	UpdateInvoice(ctx context.Context, invoice *entity.Invoice, invoiceId uuid.UUID, fromStatus string) error
+	UpdateInvoiceStatus(ctx context.Context, invoiceId uuid.UUID, fromStatus, toStatus string) error
+	MarkInvoicesOverdue(ctx context.Context, fromStatuses []string) (int, error)
 	DeleteInvoice(ctx context.Context, invoiceId uuid.UUID) error
 }
 
 type invoiceRepository struct {
-	db *bun.DB
+	db        *bun.DB
+	numbering entity.InvoiceNumbering
 }
 
-func NewInvoiceRepository(db *bun.DB) InvoiceRepository {
-	return &invoiceRepository{db}
+func NewInvoiceRepository(db *bun.DB, numbering entity.InvoiceNumbering) InvoiceRepository {
+	return &invoiceRepository{db, numbering}
 }
 
 func (ir *invoiceRepository) GetLatestInvoices(ctx context.Context, invoices *[]entity.Invoice, offset, limit int) error {
@@ -37,104 +45,402 @@ func (ir *invoiceRepository) GetLatestInvoices(ctx context.Context, invoices *[]
 		Limit(limit).
 		OrderExpr("date").
 		Scan(ctx); err != nil {
-		return err
+		return translateError(err, "invoice")
 	}
 	return nil
 }
 
 func (ir *invoiceRepository) GetInvoiceCount(ctx context.Context) (int, error) {
-	count, err := ir.db.NewSelect().Model((*entity.Invoice)(nil)).Count(ctx)
+	count, err := scopedCount(ctx, ir.db.NewSelect().Model((*entity.Invoice)(nil)))
 	if err != nil {
-		return 0, err
+		return 0, translateError(err, "invoice")
 	}
 	return count, nil
 }
 
-func (ir *invoiceRepository) GetInvoiceStatusCount(ctx context.Context) (int, int, error) {
-	pending, err := ir.db.NewSelect().Model((*entity.Invoice)(nil)).Where("status=?", "pending").Count(ctx)
-	if err != nil {
-		return 0, 0, err
+func (ir *invoiceRepository) CountInvoicesByStatus(ctx context.Context) (map[string]int, error) {
+	var rows []struct {
+		Status string
+		Count  int
 	}
-	paid, err := ir.db.NewSelect().Model((*entity.Invoice)(nil)).Where("status=?", "paid").Count(ctx)
-	if err != nil {
-		return 0, 0, err
+	q := ir.db.NewSelect().
+		Model((*entity.Invoice)(nil)).
+		Column("status").
+		ColumnExpr("count(*) AS count").
+		Group("status")
+	if err := beforeSelect(ctx, q); err != nil {
+		return nil, translateError(err, "invoice")
+	}
+	if err := q.Scan(ctx, &rows); err != nil {
+		return nil, translateError(err, "invoice")
 	}
-	return pending, paid, nil
+	counts := make(map[string]int, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
 }
 
-func (ir *invoiceRepository) GetInvoicesPages(ctx context.Context, query string, offset, limit int) (int, error) {
-	query = "%" + query + "%"
-	count, err := ir.db.NewSelect().
+func (ir *invoiceRepository) GetInvoicesPages(ctx context.Context, filter entity.InvoiceFilter, offset, limit int) (int, error) {
+	count, err := scopedCount(ctx, ir.db.NewSelect().
 		Model((*entity.Invoice)(nil)).
 		Relation("Customer").
-		WhereGroup("AND", func(q *bun.SelectQuery) *bun.SelectQuery {
-			return q.WhereOr("Customer.name ILIKE ?", query).
-				WhereOr("Customer.email ILIKE ?", query).
-				WhereOr("amount::text ILIKE ?", query).
-				WhereOr("date::text ILIKE ?", query).
-				WhereOr("status ILIKE ?", query)
-		}).
-		Count(ctx)
+		Apply(invoiceFilter(filter)))
 	if err != nil {
-		return 0, err
+		return 0, translateError(err, "invoice")
 	}
 	return count, nil
 }
 
-func (ir *invoiceRepository) GetFilteredInvoices(ctx context.Context, invoices *[]entity.Invoice, query string, offset, limit int) error {
-	query = "%" + query + "%"
+func (ir *invoiceRepository) GetFilteredInvoices(ctx context.Context, invoices *[]entity.Invoice, filter entity.InvoiceFilter, offset, limit int) error {
 	if err := ir.db.NewSelect().
 		Model(invoices).
 		Relation("Customer").
-		WhereGroup("AND", func(q *bun.SelectQuery) *bun.SelectQuery {
-			return q.WhereOr("Customer.name ILIKE ?", query).
-				WhereOr("Customer.email ILIKE ?", query).
-				WhereOr("amount::text ILIKE ?", query).
-				WhereOr("date::text ILIKE ?", query).
-				WhereOr("status ILIKE ?", query)
-		}).
-		OrderExpr("date DESC").
+		Apply(invoiceFilter(filter)).
+		Apply(invoiceSort(filter)).
 		Limit(limit).
 		Offset(offset).
 		Scan(ctx); err != nil {
-		return err
+		return translateError(err, "invoice")
 	}
 	return nil
 }
 
+func (ir *invoiceRepository) GetLatestInvoicesPage(ctx context.Context, invoices *[]entity.Invoice, cursor entity.Cursor, limit int) (bool, error) {
+	if err := keyset(ir.db.NewSelect().
+		Model(invoices).
+		Relation("Customer"), cursor, limit).
+		Scan(ctx); err != nil {
+		return false, translateError(err, "invoice")
+	}
+	return trimKeyset(invoices, cursor, limit), nil
+}
+
+// GetFilteredInvoicesPage returns the page of invoices matching filter after
+// cursor, in (date, id) order; callers reject filter.Sort beforehand. With
+// withTotal it also counts every matching invoice, reading the page and the
+// count from the same snapshot so that they agree.
+func (ir *invoiceRepository) GetFilteredInvoicesPage(ctx context.Context, invoices *[]entity.Invoice, filter entity.InvoiceFilter, cursor entity.Cursor, limit int, withTotal bool) (bool, int, error) {
+	page := func(ctx context.Context, db bun.IDB) error {
+		return keyset(db.NewSelect().
+			Model(invoices).
+			Relation("Customer").
+			Apply(invoiceFilter(filter)), cursor, limit).
+			Scan(ctx)
+	}
+	if !withTotal {
+		if err := page(ctx, ir.db); err != nil {
+			return false, 0, translateError(err, "invoice")
+		}
+		return trimKeyset(invoices, cursor, limit), 0, nil
+	}
+
+	total := 0
+	opts := &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}
+	if err := ir.db.RunInTx(ctx, opts, func(ctx context.Context, tx bun.Tx) error {
+		var err error
+		total, err = scopedCount(ctx, tx.NewSelect().
+			Model((*entity.Invoice)(nil)).
+			Relation("Customer").
+			Apply(invoiceFilter(filter)))
+		if err != nil {
+			return err
+		}
+		return page(ctx, tx)
+	}); err != nil {
+		return false, 0, translateError(err, "invoice")
+	}
+	return trimKeyset(invoices, cursor, limit), total, nil
+}
+
+// invoiceFilter restricts the query to the invoices matching filter. Query
+// matches the invoice number, customer name or email, or status as free text,
+// and the customer also by spelling similarity. Amounts and dates are searched
+// with the range filters instead.
+func invoiceFilter(filter entity.InvoiceFilter) func(q *bun.SelectQuery) *bun.SelectQuery {
+	return func(q *bun.SelectQuery) *bun.SelectQuery {
+		if filter.Query != "" {
+			query := "%" + filter.Query + "%"
+			q = q.WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+				return q.WhereOr("Customer.name ILIKE ?", query).
+					WhereOr("Customer.email ILIKE ?", query).
+					WhereOr(invoiceCustomerMatch, filter.Query).
+					WhereOr("i.number ILIKE ?", query).
+					WhereOr("i.status ILIKE ?", query)
+			})
+		}
+		if filter.Status != "" {
+			q = q.Where("i.status = ?", filter.Status)
+		}
+		if filter.Overdue {
+			q = q.Where("i.status IN (?)", bun.In(entity.OutstandingInvoiceStatuses)).
+				Where("i.due_date < CURRENT_DATE")
+		}
+		if filter.CustomerId != uuid.Nil {
+			q = q.Where("i.customer_id = ?", filter.CustomerId)
+		}
+		if filter.AmountMin != nil {
+			q = q.Where("i.amount >= ?", *filter.AmountMin)
+		}
+		if filter.AmountMax != nil {
+			q = q.Where("i.amount <= ?", *filter.AmountMax)
+		}
+		if !filter.DateFrom.IsZero() {
+			q = q.Where("i.date >= ?", filter.DateFrom.Format(time.DateOnly))
+		}
+		if !filter.DateTo.IsZero() {
+			q = q.Where("i.date <= ?", filter.DateTo.Format(time.DateOnly))
+		}
+		return q
+	}
+}
+
+// SQL に埋め込む列はこの対応表にあるものだけに限る
+var invoiceSortColumns = map[string]string{
+	entity.InvoiceSortDate:    "i.date",
+	entity.InvoiceSortDueDate: "i.due_date",
+	entity.InvoiceSortAmount:  "i.amount",
+	entity.InvoiceSortStatus:  "i.status",
+	entity.InvoiceSortName:    "customer.name",
+	entity.InvoiceSortEmail:   "customer.email",
+	entity.InvoiceSortNumber:  "i.number",
+}
+
+// invoiceSort orders the query by filter.Sort. Without it, searches are
+// ordered by how well the customer matches and listings newest first. The id
+// breaks ties so that offset pages do not overlap.
+func invoiceSort(filter entity.InvoiceFilter) func(q *bun.SelectQuery) *bun.SelectQuery {
+	return func(q *bun.SelectQuery) *bun.SelectQuery {
+		if len(filter.Sort) == 0 {
+			if filter.Query != "" {
+				q = q.OrderExpr(invoiceCustomerScore+" DESC", filter.Query)
+			}
+			return q.OrderExpr("i.date DESC, i.id DESC")
+		}
+		for _, field := range filter.Sort {
+			column, ok := invoiceSortColumns[field.Field]
+			if !ok {
+				continue
+			}
+			if field.Desc {
+				q = q.OrderExpr(column + " DESC")
+			} else {
+				q = q.OrderExpr(column + " ASC")
+			}
+		}
+		return q.OrderExpr("i.id ASC")
+	}
+}
+
 func (ir *invoiceRepository) GetInvoiceById(ctx context.Context, invoice *entity.Invoice, invoiceId uuid.UUID) error {
-	if err := ir.db.NewSelect().
-		Model(invoice).
-		Relation("Customer").
+	if err := selectInvoiceDetails(ir.db.NewSelect().Model(invoice)).
 		Where("i.id=?", invoiceId).
 		Scan(ctx); err != nil {
-		return err
+		return translateError(err, "invoice")
 	}
 	return nil
 }
 
+func (ir *invoiceRepository) GetInvoiceByNumber(ctx context.Context, invoice *entity.Invoice, number string) error {
+	if err := selectInvoiceDetails(ir.db.NewSelect().Model(invoice)).
+		Where("i.number=?", number).
+		Scan(ctx); err != nil {
+		return translateError(err, "invoice")
+	}
+	return nil
+}
+
+// selectInvoiceDetails loads an invoice with everything shown on its detail
+// page.
+func selectInvoiceDetails(q *bun.SelectQuery) *bun.SelectQuery {
+	return q.
+		Relation("Customer").
+		Relation("Items", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.Order("ii.position ASC")
+		}).
+		Relation("StatusHistory", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.Order("ish.changed_at ASC")
+		}).
+		Relation("Payments", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.Order("p.received_at ASC", "p.created_at ASC")
+		}).
+		Relation("Payments.Refunds", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.Order("rf.refunded_at ASC", "rf.created_at ASC")
+		}).
+		Relation("CreditNotes", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.Order("cn.issued_at ASC", "cn.created_at ASC")
+		})
+}
+
 func (ir *invoiceRepository) CreateInvoice(ctx context.Context, invoice *entity.Invoice) error {
-	if _, err := ir.db.NewInsert().Model(invoice).Exec(ctx); err != nil {
+	return ir.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		return createInvoice(ctx, tx, invoice, ir.numbering)
+	})
+}
+
+// createInvoice numbers and inserts invoice with its items, its initial
+// status and any payments it was created with.
+func createInvoice(ctx context.Context, tx bun.Tx, invoice *entity.Invoice, numbering entity.InvoiceNumbering) error {
+	if err := allocateInvoiceNumber(ctx, tx, invoice, numbering); err != nil {
 		return err
 	}
-	return nil
+	if _, err := tx.NewInsert().Model(invoice).Exec(ctx); err != nil {
+		return translateError(err, "invoice")
+	}
+	if err := insertStatusChange(ctx, tx, invoice.ID, "", invoice.Status); err != nil {
+		return err
+	}
+	if len(invoice.Payments) > 0 {
+		for i := range invoice.Payments {
+			invoice.Payments[i].InvoiceId = invoice.ID
+		}
+		if _, err := tx.NewInsert().Model(&invoice.Payments).Exec(ctx); err != nil {
+			return translateError(err, "payment")
+		}
+	}
+	return insertInvoiceItems(ctx, tx, invoice)
+}
+
+// UpdateInvoice overwrites the invoice and its items, provided its status is
+// still fromStatus. A status change is recorded in the history.
+func (ir *invoiceRepository) UpdateInvoice(ctx context.Context, invoice *entity.Invoice, invoiceId uuid.UUID, fromStatus string) error {
+	return ir.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		result, err := tx.NewUpdate().
+			Model(invoice).
+			Column("customer_id", "subtotal", "tax", "amount", "status", "payment_terms", "due_date").
+			Where("id=?", invoiceId).
+			Where("status=?", fromStatus).
+			Exec(ctx)
+		if err != nil {
+			return translateError(err, "invoice")
+		}
+		if err := checkStatusUpdated(result); err != nil {
+			return err
+		}
+		if invoice.Status != fromStatus {
+			if err := insertStatusChange(ctx, tx, invoiceId, fromStatus, invoice.Status); err != nil {
+				return err
+			}
+		}
+
+		if _, err := tx.NewDelete().
+			Model((*entity.InvoiceItem)(nil)).
+			Where("invoice_id=?", invoiceId).
+			Exec(ctx); err != nil {
+			return translateError(err, "invoice")
+		}
+		invoice.ID = invoiceId
+		return insertInvoiceItems(ctx, tx, invoice)
+	})
+}
+
+// UpdateInvoiceStatus moves the invoice from fromStatus to toStatus and
+// records the transition. It fails with a conflict if the status has changed
+// in the meantime.
+func (ir *invoiceRepository) UpdateInvoiceStatus(ctx context.Context, invoiceId uuid.UUID, fromStatus, toStatus string) error {
+	return ir.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		return updateInvoiceStatus(ctx, tx, invoiceId, fromStatus, toStatus)
+	})
 }
 
-func (ir *invoiceRepository) UpdateInvoice(ctx context.Context, invoice *entity.Invoice, invoiceId uuid.UUID) error {
-	result, err := ir.db.NewUpdate().
-		Model(invoice).
-		Column("customer_id", "amount", "status").
+func updateInvoiceStatus(ctx context.Context, tx bun.Tx, invoiceId uuid.UUID, fromStatus, toStatus string) error {
+	result, err := tx.NewUpdate().
+		Model((*entity.Invoice)(nil)).
+		Set("status=?", toStatus).
 		Where("id=?", invoiceId).
+		Where("status=?", fromStatus).
 		Exec(ctx)
 	if err != nil {
+		return translateError(err, "invoice")
+	}
+	if err := checkStatusUpdated(result); err != nil {
 		return err
 	}
+	return insertStatusChange(ctx, tx, invoiceId, fromStatus, toStatus)
+}
+
+// MarkInvoicesOverdue moves the invoices in one of fromStatuses whose due date
+// has passed to overdue, records each transition and returns how many moved.
+func (ir *invoiceRepository) MarkInvoicesOverdue(ctx context.Context, fromStatuses []string) (int, error) {
+	changes := []entity.InvoiceStatusChange{}
+	if err := ir.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		invoices := []entity.Invoice{}
+		// 他のリクエストが更新中の請求書は次の実行に回す
+		if err := tx.NewSelect().
+			Model(&invoices).
+			Column("id", "organization_id", "status").
+			Where("i.status IN (?)", bun.In(fromStatuses)).
+			Where("i.due_date < CURRENT_DATE").
+			For("UPDATE SKIP LOCKED").
+			Scan(ctx); err != nil {
+			return err
+		}
+		if len(invoices) == 0 {
+			return nil
+		}
+
+		invoiceIds := make([]uuid.UUID, len(invoices))
+		for i, invoice := range invoices {
+			invoiceIds[i] = invoice.ID
+			changes = append(changes, entity.InvoiceStatusChange{
+				OrganizationId: invoice.OrganizationId,
+				InvoiceId:      invoice.ID,
+				FromStatus:     sql.NullString{String: invoice.Status, Valid: true},
+				ToStatus:       entity.InvoiceStatusOverdue,
+			})
+		}
+		if _, err := tx.NewUpdate().
+			Model((*entity.Invoice)(nil)).
+			Set("status=?", entity.InvoiceStatusOverdue).
+			Where("i.id IN (?)", bun.In(invoiceIds)).
+			Exec(ctx); err != nil {
+			return err
+		}
+		_, err := tx.NewInsert().Model(&changes).Exec(ctx)
+		return err
+	}); err != nil {
+		return 0, translateError(err, "invoice")
+	}
+	return len(changes), nil
+}
+
+// checkStatusUpdated reports a conflict when an update guarded by the
+// expected status matched no invoice.
+func checkStatusUpdated(result sql.Result) error {
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return err
+		return translateError(err, "invoice")
 	}
 	if rowsAffected < 1 {
-		return fmt.Errorf("object does not exist")
+		// 読み込んでから更新するまでの間に他のリクエストが状態を変えた
+		return apperror.Conflict("invoice was modified concurrently")
+	}
+	return nil
+}
+
+func insertStatusChange(ctx context.Context, tx bun.Tx, invoiceId uuid.UUID, fromStatus, toStatus string) error {
+	change := entity.InvoiceStatusChange{
+		InvoiceId:  invoiceId,
+		FromStatus: sql.NullString{String: fromStatus, Valid: fromStatus != ""},
+		ToStatus:   toStatus,
+	}
+	if _, err := tx.NewInsert().Model(&change).Exec(ctx); err != nil {
+		return translateError(err, "invoice")
+	}
+	return nil
+}
+
+func insertInvoiceItems(ctx context.Context, tx bun.Tx, invoice *entity.Invoice) error {
+	if len(invoice.Items) == 0 {
+		return nil
+	}
+	for i := range invoice.Items {
+		invoice.Items[i].ID = uuid.Nil
+		invoice.Items[i].InvoiceId = invoice.ID
+		invoice.Items[i].Position = i
+	}
+	if _, err := tx.NewInsert().Model(&invoice.Items).Exec(ctx); err != nil {
+		return translateError(err, "invoice")
 	}
 	return nil
 }
@@ -145,14 +451,14 @@ func (ir *invoiceRepository) DeleteInvoice(ctx context.Context, invoiceId uuid.U
 		Where("id=?", invoiceId).
 		Exec(ctx)
 	if err != nil {
-		return err
+		return translateError(err, "invoice")
 	}
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return err
+		return translateError(err, "invoice")
 	}
 	if rowsAffected < 1 {
-		return fmt.Errorf("object does not exist")
+		return apperror.NotFound("invoice not found")
 	}
 	return nil
 }
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"next-learn-go/entity"
+	"next-learn-go/tenant"
+	"os"
+
+	"github.com/uptrace/bun"
+)
+
+// InvoiceNumberingFromEnv reads INVOICE_NUMBER_FORMAT and INVOICE_NUMBER_RESET
+// ("yearly" or "never"). Unset variables take the default numbering, but
+// invalid ones are an error: falling back would silently issue numbers in
+// another series.
+func InvoiceNumberingFromEnv() (entity.InvoiceNumbering, error) {
+	numbering := entity.DefaultInvoiceNumbering()
+	format := os.Getenv("INVOICE_NUMBER_FORMAT")
+	if format == "" {
+		format = numbering.Format
+	}
+	resetYearly := numbering.ResetYearly
+	switch v := os.Getenv("INVOICE_NUMBER_RESET"); v {
+	case "":
+	case "yearly":
+		resetYearly = true
+	case "never":
+		resetYearly = false
+	default:
+		return entity.InvoiceNumbering{}, fmt.Errorf("invalid INVOICE_NUMBER_RESET %q: must be yearly or never", v)
+	}
+	return entity.ParseInvoiceNumbering(format, resetYearly)
+}
+
+// allocateInvoiceNumber gives invoice the next number of its organization's
+// sequence for the invoice date. The number is only taken if tx commits, so
+// numbers are handed out without gaps.
+func allocateInvoiceNumber(ctx context.Context, tx bun.Tx, invoice *entity.Invoice, numbering entity.InvoiceNumbering) error {
+	// 採番する組織は挿入時のフックと同じ規則で決める
+	if err := tenant.Assign(ctx, &invoice.OrganizationId); err != nil {
+		return err
+	}
+	seq, err := nextSequenceValue(ctx, tx, invoice.OrganizationId, numbering.SequenceName(invoice.Date))
+	if err != nil {
+		return err
+	}
+	invoice.Number = numbering.Number(invoice.Date, seq)
+	return nil
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"next-learn-go/apperror"
+	"next-learn-go/entity"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+type OrganizationRepository interface {
+	GetMembership(ctx context.Context, member *entity.OrganizationMember, organizationId, userId uuid.UUID) error
+	GetMembershipsByUser(ctx context.Context, members *[]entity.OrganizationMember, userId uuid.UUID) error
+	GetMembersByOrganization(ctx context.Context, members *[]entity.OrganizationMember, organizationId uuid.UUID) error
+	AddMember(ctx context.Context, member *entity.OrganizationMember) error
+	UpdateMemberRole(ctx context.Context, organizationId, userId uuid.UUID, role string) error
+}
+
+type organizationRepository struct {
+	db *bun.DB
+}
+
+func NewOrganizationRepository(db *bun.DB) OrganizationRepository {
+	return &organizationRepository{db}
+}
+
+func (or *organizationRepository) GetMembership(ctx context.Context, member *entity.OrganizationMember, organizationId, userId uuid.UUID) error {
+	if err := or.db.NewSelect().
+		Model(member).
+		Relation("Organization").
+		Where("om.organization_id=?", organizationId).
+		Where("om.user_id=?", userId).
+		Scan(ctx); err != nil {
+		return translateError(err, "organization member")
+	}
+	return nil
+}
+
+func (or *organizationRepository) GetMembershipsByUser(ctx context.Context, members *[]entity.OrganizationMember, userId uuid.UUID) error {
+	if err := or.db.NewSelect().
+		Model(members).
+		Relation("Organization").
+		Where("om.user_id=?", userId).
+		Order("om.created_at ASC").
+		Scan(ctx); err != nil {
+		return translateError(err, "organization member")
+	}
+	return nil
+}
+
+func (or *organizationRepository) GetMembersByOrganization(ctx context.Context, members *[]entity.OrganizationMember, organizationId uuid.UUID) error {
+	if err := or.db.NewSelect().
+		Model(members).
+		Relation("User").
+		Where("om.organization_id=?", organizationId).
+		Order("user.name ASC").
+		Scan(ctx); err != nil {
+		return translateError(err, "organization member")
+	}
+	return nil
+}
+
+func (or *organizationRepository) AddMember(ctx context.Context, member *entity.OrganizationMember) error {
+	if _, err := or.db.NewInsert().Model(member).Exec(ctx); err != nil {
+		return translateError(err, "organization member")
+	}
+	return nil
+}
+
+// UpdateMemberRole changes the member's role. It refuses to demote the last
+// admin, locking the organization's admins so that two admins demoting each
+// other at the same time cannot both succeed.
+func (or *organizationRepository) UpdateMemberRole(ctx context.Context, organizationId, userId uuid.UUID, role string) error {
+	return or.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		admins := []entity.OrganizationMember{}
+		if err := tx.NewSelect().
+			Model(&admins).
+			Column("user_id").
+			Where("om.organization_id=?", organizationId).
+			Where("om.role=?", entity.RoleAdmin).
+			For("UPDATE").
+			Scan(ctx); err != nil {
+			return translateError(err, "organization member")
+		}
+		if role != entity.RoleAdmin && len(admins) == 1 && admins[0].UserId == userId {
+			return apperror.Conflict("an organization must keep at least one admin")
+		}
+
+		result, err := tx.NewUpdate().
+			Model((*entity.OrganizationMember)(nil)).
+			Set("role=?", role).
+			Where("organization_id=?", organizationId).
+			Where("user_id=?", userId).
+			Exec(ctx)
+		if err != nil {
+			return translateError(err, "organization member")
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return translateError(err, "organization member")
+		}
+		if rowsAffected < 1 {
+			return apperror.NotFound("organization member not found")
+		}
+		return nil
+	})
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"next-learn-go/entity"
+
+	"github.com/uptrace/bun"
+)
+
+// keyset restricts q to the page after (or, for a backward cursor, before)
+// cursor in (date DESC, id DESC) order. One row more than limit is fetched so
+// that trimKeyset can tell whether another page follows.
+func keyset(q *bun.SelectQuery, cursor entity.Cursor, limit int) *bun.SelectQuery {
+	if !cursor.IsZero() {
+		if cursor.Backward {
+			q = q.Where("(?TableAlias.date, ?TableAlias.id) > (?, ?)", cursor.Date, cursor.ID)
+		} else {
+			q = q.Where("(?TableAlias.date, ?TableAlias.id) < (?, ?)", cursor.Date, cursor.ID)
+		}
+	}
+	// 後ろ向きのページは昇順で取得し、trimKeyset で並べ直す
+	if cursor.Backward {
+		q = q.OrderExpr("?TableAlias.date ASC, ?TableAlias.id ASC")
+	} else {
+		q = q.OrderExpr("?TableAlias.date DESC, ?TableAlias.id DESC")
+	}
+	return q.Limit(limit + 1)
+}
+
+// trimKeyset drops the extra row fetched by keyset, puts the rows back into
+// (date DESC, id DESC) order and reports whether there are more rows in the
+// direction of the cursor.
+func trimKeyset(invoices *[]entity.Invoice, cursor entity.Cursor, limit int) bool {
+	rows := *invoices
+	hasMore := len(rows) > limit
+	if hasMore {
+		rows = rows[:limit]
+	}
+	if cursor.Backward {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+	*invoices = rows
+	return hasMore
+}
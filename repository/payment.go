@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"next-learn-go/apperror"
+	"next-learn-go/entity"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// SettleFunc decides the status of invoice once its balance has changed, or
+// rejects the change.
+type SettleFunc func(invoice entity.Invoice, balance entity.InvoiceBalance) (string, error)
+
+type PaymentRepository interface {
+	GetPayments(ctx context.Context, payments *[]entity.Payment, invoiceId uuid.UUID) error
+	CreatePayment(ctx context.Context, payment *entity.Payment, settle SettleFunc) error
+	DeletePayment(ctx context.Context, invoiceId, paymentId uuid.UUID, settle SettleFunc) error
+	CreateRefund(ctx context.Context, refund *entity.Refund, invoiceId uuid.UUID, settle SettleFunc) error
+}
+
+type paymentRepository struct {
+	db *bun.DB
+}
+
+func NewPaymentRepository(db *bun.DB) PaymentRepository {
+	return &paymentRepository{db}
+}
+
+func (pr *paymentRepository) GetPayments(ctx context.Context, payments *[]entity.Payment, invoiceId uuid.UUID) error {
+	if err := pr.db.NewSelect().
+		Model(payments).
+		Relation("Refunds", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.Order("rf.refunded_at ASC", "rf.created_at ASC")
+		}).
+		Where("p.invoice_id=?", invoiceId).
+		Order("p.received_at ASC", "p.created_at ASC").
+		Scan(ctx); err != nil {
+		return translateError(err, "payment")
+	}
+	return nil
+}
+
+// CreatePayment records payment and moves its invoice to the status settle
+// returns for the new total paid.
+func (pr *paymentRepository) CreatePayment(ctx context.Context, payment *entity.Payment, settle SettleFunc) error {
+	return pr.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		invoice, balance, err := lockInvoiceBalance(ctx, tx, payment.InvoiceId)
+		if err != nil {
+			return err
+		}
+		balance.Paid += payment.Amount
+		status, err := settle(invoice, balance)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.NewInsert().Model(payment).Exec(ctx); err != nil {
+			return translateError(err, "payment")
+		}
+		if status != invoice.Status {
+			return updateInvoiceStatus(ctx, tx, invoice.ID, invoice.Status, status)
+		}
+		return nil
+	})
+}
+
+// DeletePayment removes a payment recorded in error and moves its invoice to
+// the status settle returns for what remains paid.
+func (pr *paymentRepository) DeletePayment(ctx context.Context, invoiceId, paymentId uuid.UUID, settle SettleFunc) error {
+	return pr.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		invoice, balance, err := lockInvoiceBalance(ctx, tx, invoiceId)
+		if err != nil {
+			return err
+		}
+		payment, err := getInvoicePayment(ctx, tx, invoiceId, paymentId)
+		if err != nil {
+			return err
+		}
+		// 返金の記録が宙に浮くため、返金済みの入金は削除させない
+		if len(payment.Refunds) > 0 {
+			return apperror.Conflict("refunded payments cannot be deleted")
+		}
+		balance.Paid -= payment.Amount
+		status, err := settle(invoice, balance)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.NewDelete().
+			Model(&entity.Payment{}).
+			Where("id=?", paymentId).
+			Exec(ctx); err != nil {
+			return translateError(err, "payment")
+		}
+		if status != invoice.Status {
+			return updateInvoiceStatus(ctx, tx, invoice.ID, invoice.Status, status)
+		}
+		return nil
+	})
+}
+
+// CreateRefund records refund against one of the invoice's payments and moves
+// the invoice to the status settle returns for the reduced amount paid.
+func (pr *paymentRepository) CreateRefund(ctx context.Context, refund *entity.Refund, invoiceId uuid.UUID, settle SettleFunc) error {
+	return pr.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		invoice, balance, err := lockInvoiceBalance(ctx, tx, invoiceId)
+		if err != nil {
+			return err
+		}
+		payment, err := getInvoicePayment(ctx, tx, invoiceId, refund.PaymentId)
+		if err != nil {
+			return err
+		}
+		refundable := payment.Amount
+		for _, v := range payment.Refunds {
+			refundable -= v.Amount
+		}
+		if refund.Amount > refundable {
+			return apperror.InvalidField("amount", fmt.Sprintf("refunds would exceed the %d left on the payment", refundable))
+		}
+		balance.Refunded += refund.Amount
+		status, err := settle(invoice, balance)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.NewInsert().Model(refund).Exec(ctx); err != nil {
+			return translateError(err, "refund")
+		}
+		if status != invoice.Status {
+			return updateInvoiceStatus(ctx, tx, invoice.ID, invoice.Status, status)
+		}
+		return nil
+	})
+}
+
+func getInvoicePayment(ctx context.Context, tx bun.Tx, invoiceId, paymentId uuid.UUID) (entity.Payment, error) {
+	payment := entity.Payment{}
+	if err := tx.NewSelect().
+		Model(&payment).
+		Relation("Refunds").
+		Where("p.id=?", paymentId).
+		Where("p.invoice_id=?", invoiceId).
+		Scan(ctx); err != nil {
+		return entity.Payment{}, translateError(err, "payment")
+	}
+	return payment, nil
+}
+
+// lockInvoiceBalance locks the invoice so that changes to its balance are
+// applied one after another, and returns it with its balance so far. The
+// invoice has already been checked against the organization, so its
+// payments, refunds and credit notes are summed by invoice alone.
+func lockInvoiceBalance(ctx context.Context, tx bun.Tx, invoiceId uuid.UUID) (entity.Invoice, entity.InvoiceBalance, error) {
+	invoice := entity.Invoice{}
+	if err := tx.NewSelect().
+		Model(&invoice).
+		Column("id", "organization_id", "amount", "status", "due_date").
+		Where("i.id=?", invoiceId).
+		For("UPDATE").
+		Scan(ctx); err != nil {
+		return entity.Invoice{}, entity.InvoiceBalance{}, translateError(err, "invoice")
+	}
+	balance := entity.InvoiceBalance{}
+	if err := tx.NewSelect().
+		ColumnExpr("(SELECT COALESCE(SUM(p.amount), 0) FROM payments AS p WHERE p.invoice_id = ?) AS paid", invoiceId).
+		ColumnExpr("(SELECT COALESCE(SUM(rf.amount), 0) FROM refunds AS rf JOIN payments AS p ON p.id = rf.payment_id WHERE p.invoice_id = ?) AS refunded", invoiceId).
+		ColumnExpr("(SELECT COALESCE(SUM(cn.amount), 0) FROM credit_notes AS cn WHERE cn.invoice_id = ?) AS credited", invoiceId).
+		Scan(ctx, &balance); err != nil {
+		return entity.Invoice{}, entity.InvoiceBalance{}, translateError(err, "payment")
+	}
+	return invoice, balance, nil
+}
@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"next-learn-go/apperror"
+	"next-learn-go/entity"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// GenerateFunc builds the invoice recurringInvoice bills on its next date and
+// returns the date after that, which is null once the schedule has ended.
+type GenerateFunc func(recurringInvoice entity.RecurringInvoice) (entity.Invoice, bun.NullTime, error)
+
+type RecurringInvoiceRepository interface {
+	GetRecurringInvoices(ctx context.Context, recurringInvoices *[]entity.RecurringInvoice) error
+	GetRecurringInvoiceById(ctx context.Context, recurringInvoice *entity.RecurringInvoice, recurringInvoiceId uuid.UUID) error
+	GetDueRecurringInvoices(ctx context.Context, recurringInvoices *[]entity.RecurringInvoice, today time.Time) error
+	CreateRecurringInvoice(ctx context.Context, recurringInvoice *entity.RecurringInvoice) error
+	UpdateRecurringInvoice(ctx context.Context, recurringInvoice *entity.RecurringInvoice, recurringInvoiceId uuid.UUID) error
+	DeleteRecurringInvoice(ctx context.Context, recurringInvoiceId uuid.UUID) error
+	GenerateInvoices(ctx context.Context, recurringInvoiceId uuid.UUID, today time.Time, generate GenerateFunc) (int, error)
+}
+
+type recurringInvoiceRepository struct {
+	db        *bun.DB
+	numbering entity.InvoiceNumbering
+}
+
+func NewRecurringInvoiceRepository(db *bun.DB, numbering entity.InvoiceNumbering) RecurringInvoiceRepository {
+	return &recurringInvoiceRepository{db, numbering}
+}
+
+func (rr *recurringInvoiceRepository) GetRecurringInvoices(ctx context.Context, recurringInvoices *[]entity.RecurringInvoice) error {
+	if err := rr.db.NewSelect().
+		Model(recurringInvoices).
+		Order("ri.created_at ASC", "ri.id ASC").
+		Scan(ctx); err != nil {
+		return translateError(err, "recurring invoice")
+	}
+	return nil
+}
+
+func (rr *recurringInvoiceRepository) GetRecurringInvoiceById(ctx context.Context, recurringInvoice *entity.RecurringInvoice, recurringInvoiceId uuid.UUID) error {
+	if err := rr.db.NewSelect().
+		Model(recurringInvoice).
+		Relation("Customer").
+		Where("ri.id=?", recurringInvoiceId).
+		Scan(ctx); err != nil {
+		return translateError(err, "recurring invoice")
+	}
+	return nil
+}
+
+// GetDueRecurringInvoices returns the recurring invoices with an invoice due
+// on or before today.
+func (rr *recurringInvoiceRepository) GetDueRecurringInvoices(ctx context.Context, recurringInvoices *[]entity.RecurringInvoice, today time.Time) error {
+	if err := rr.db.NewSelect().
+		Model(recurringInvoices).
+		Column("id", "organization_id").
+		Where("ri.next_date <= ?", today.Format("2006-01-02")).
+		Order("ri.next_date ASC").
+		Scan(ctx); err != nil {
+		return translateError(err, "recurring invoice")
+	}
+	return nil
+}
+
+func (rr *recurringInvoiceRepository) CreateRecurringInvoice(ctx context.Context, recurringInvoice *entity.RecurringInvoice) error {
+	if _, err := rr.db.NewInsert().Model(recurringInvoice).Exec(ctx); err != nil {
+		return translateError(err, "recurring invoice")
+	}
+	return nil
+}
+
+func (rr *recurringInvoiceRepository) UpdateRecurringInvoice(ctx context.Context, recurringInvoice *entity.RecurringInvoice, recurringInvoiceId uuid.UUID) error {
+	result, err := rr.db.NewUpdate().
+		Model(recurringInvoice).
+		Column("customer_id", "description", "amount", "tax_rate", "frequency", "day_of_month", "start_date", "end_date", "next_date").
+		Where("id=?", recurringInvoiceId).
+		Exec(ctx)
+	if err != nil {
+		return translateError(err, "recurring invoice")
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return translateError(err, "recurring invoice")
+	}
+	if rowsAffected < 1 {
+		return apperror.NotFound("recurring invoice not found")
+	}
+	return nil
+}
+
+// DeleteRecurringInvoice stops the schedule. Invoices already created from it
+// are kept.
+func (rr *recurringInvoiceRepository) DeleteRecurringInvoice(ctx context.Context, recurringInvoiceId uuid.UUID) error {
+	result, err := rr.db.NewDelete().
+		Model(&entity.RecurringInvoice{}).
+		Where("id=?", recurringInvoiceId).
+		Exec(ctx)
+	if err != nil {
+		return translateError(err, "recurring invoice")
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return translateError(err, "recurring invoice")
+	}
+	if rowsAffected < 1 {
+		return apperror.NotFound("recurring invoice not found")
+	}
+	return nil
+}
+
+// GenerateInvoices creates the invoices the recurring invoice has due on or
+// before today, catching up on any dates missed while no instance was
+// running, and returns how many were created. The recurring invoice is locked
+// while it is billed and an invoice that already exists for a date is never
+// created again, so running it again or from several instances at once does
+// not bill twice.
+func (rr *recurringInvoiceRepository) GenerateInvoices(ctx context.Context, recurringInvoiceId uuid.UUID, today time.Time, generate GenerateFunc) (int, error) {
+	count := 0
+	if err := rr.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		count = 0
+		recurringInvoice := entity.RecurringInvoice{}
+		// 他のインスタンスが処理中のひな形は飛ばす
+		if err := tx.NewSelect().
+			Model(&recurringInvoice).
+			Relation("Customer").
+			Where("ri.id=?", recurringInvoiceId).
+			Where("ri.next_date <= ?", today.Format("2006-01-02")).
+			For("UPDATE OF ri SKIP LOCKED").
+			Scan(ctx); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil
+			}
+			return translateError(err, "recurring invoice")
+		}
+
+		for !recurringInvoice.NextDate.IsZero() && !recurringInvoice.NextDate.Time.After(today) {
+			invoice, next, err := generate(recurringInvoice)
+			if err != nil {
+				return err
+			}
+			exists, err := scopedExists(ctx, tx.NewSelect().
+				Model((*entity.Invoice)(nil)).
+				Where("i.recurring_invoice_id=?", recurringInvoice.ID).
+				Where("i.recurrence_date=?", recurringInvoice.NextDate.Time.Format("2006-01-02")))
+			if err != nil {
+				return translateError(err, "invoice")
+			}
+			if !exists {
+				if err := createInvoice(ctx, tx, &invoice, rr.numbering); err != nil {
+					return err
+				}
+				count++
+			}
+			recurringInvoice.LastInvoiceDate = recurringInvoice.NextDate
+			recurringInvoice.NextDate = next
+		}
+
+		if _, err := tx.NewUpdate().
+			Model(&recurringInvoice).
+			Column("next_date", "last_invoice_date").
+			WherePK().
+			Exec(ctx); err != nil {
+			return translateError(err, "recurring invoice")
+		}
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
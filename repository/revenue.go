@@ -2,13 +2,14 @@ package repository
 
 import (
 	"context"
+	"next-learn-go/apperror"
 	"next-learn-go/entity"
 
 	"github.com/uptrace/bun"
 )
 
 type RevenueRepository interface {
-	GetAllRevenues(ctx context.Context, revenues *[]entity.Revenue) error
+	GetRevenuePeriods(ctx context.Context, periods *[]entity.RevenuePeriod, query entity.RevenueQuery) error
 }
 
 type revenueRepository struct {
@@ -19,11 +20,81 @@ func NewRevenueRepository(db *bun.DB) RevenueRepository {
 	return &revenueRepository{db}
 }
 
-func (rr *revenueRepository) GetAllRevenues(ctx context.Context, revenues *[]entity.Revenue) error {
+var revenueIntervals = map[string]string{
+	entity.GranularityDay:     "1 day",
+	entity.GranularityWeek:    "1 week",
+	entity.GranularityMonth:   "1 month",
+	entity.GranularityQuarter: "3 months",
+	entity.GranularityYear:    "1 year",
+}
+
+func (rr *revenueRepository) GetRevenuePeriods(ctx context.Context, periods *[]entity.RevenuePeriod, query entity.RevenueQuery) error {
+	interval, ok := revenueIntervals[query.Granularity]
+	if !ok {
+		return apperror.InvalidField("granularity", "granularity must be day, week, month, quarter or year")
+	}
+
+	from := query.From.Format("2006-01-02")
+	to := query.To.Format("2006-01-02")
+
+	// 売上は受け取った入金から返金を差し引いた額とし、それぞれ入金日と返金日の
+	// 期間に数える。一部だけ返金された請求書も返金額だけ売上が減る。With の
+	// 副問い合わせにはフックが効かないため、組織の絞り込みは beforeSelect で
+	// 明示的に付ける
+	received := rr.db.NewSelect().
+		Model((*entity.Payment)(nil)).
+		ColumnExpr("date_trunc(?, p.received_at::date::timestamp) AS period_start", query.Granularity).
+		ColumnExpr("SUM(p.amount) AS amount").
+		Where("p.received_at::date >= ?", from).
+		Where("p.received_at::date <= ?", to).
+		GroupExpr("1")
+	if err := beforeSelect(ctx, received); err != nil {
+		return translateError(err, "revenue")
+	}
+	refunded := rr.db.NewSelect().
+		Model((*entity.Refund)(nil)).
+		ColumnExpr("date_trunc(?, rf.refunded_at::date::timestamp) AS period_start", query.Granularity).
+		ColumnExpr("SUM(rf.amount) AS amount").
+		Where("rf.refunded_at::date >= ?", from).
+		Where("rf.refunded_at::date <= ?", to).
+		GroupExpr("1")
+	if err := beforeSelect(ctx, refunded); err != nil {
+		return translateError(err, "revenue")
+	}
+	// 貸方票は請求額を減らすだけで入金は伴わないため、売上には含めず発行した
+	// 期間ごとに示す
+	credited := rr.db.NewSelect().
+		Model((*entity.CreditNote)(nil)).
+		ColumnExpr("date_trunc(?, cn.issued_at::date::timestamp) AS period_start", query.Granularity).
+		ColumnExpr("SUM(cn.amount) AS amount").
+		Where("cn.issued_at::date >= ?", from).
+		Where("cn.issued_at::date <= ?", to).
+		GroupExpr("1")
+	if err := beforeSelect(ctx, credited); err != nil {
+		return translateError(err, "revenue")
+	}
+
+	// 売上のない期間も 0 で返すため、期間の一覧を generate_series で作って外部結合する
 	if err := rr.db.NewSelect().
-		Model(revenues).
-		Scan(ctx); err != nil {
-		return err
+		With("received", received).
+		With("refunded", refunded).
+		With("credited", credited).
+		TableExpr(
+			"generate_series(date_trunc(?, ?::timestamp), date_trunc(?, ?::timestamp), ?::interval) AS p(period_start)",
+			query.Granularity, from,
+			query.Granularity, to,
+			interval,
+		).
+		ColumnExpr("p.period_start").
+		ColumnExpr("COALESCE(received.amount, 0) - COALESCE(refunded.amount, 0) AS revenue").
+		ColumnExpr("COALESCE(credited.amount, 0) AS credited").
+		ColumnExpr("COALESCE(refunded.amount, 0) AS refunded").
+		Join("LEFT JOIN received ON received.period_start = p.period_start").
+		Join("LEFT JOIN refunded ON refunded.period_start = p.period_start").
+		Join("LEFT JOIN credited ON credited.period_start = p.period_start").
+		OrderExpr("p.period_start ASC").
+		Scan(ctx, periods); err != nil {
+		return translateError(err, "revenue")
 	}
 	return nil
 }
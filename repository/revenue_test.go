@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"next-learn-go/entity"
+	"testing"
+	"time"
+)
+
+func TestRevenuePeriodsPartialRefund(t *testing.T) {
+	db := openTestDB(t)
+	f := seedTenant(t, db, "revenue", 1000)
+
+	invoice := entity.Invoice{}
+	if err := NewInvoiceRepository(db, entity.DefaultInvoiceNumbering()).GetInvoiceById(f.ctx, &invoice, f.paid.ID); err != nil {
+		t.Fatal(err)
+	}
+	refund := entity.Refund{
+		PaymentId:  invoice.Payments[0].ID,
+		Amount:     300,
+		Reason:     "overbilled",
+		RefundedAt: time.Date(2001, 2, 10, 0, 0, 0, 0, time.UTC),
+	}
+	// 一部返金で請求書は支払い済みでなくなるが、売上からは返金額だけが減る
+	settle := func(entity.Invoice, entity.InvoiceBalance) (string, error) {
+		return entity.InvoiceStatusPartiallyPaid, nil
+	}
+	if err := NewPaymentRepository(db).CreateRefund(f.ctx, &refund, f.paid.ID, settle); err != nil {
+		t.Fatal(err)
+	}
+
+	periods := []entity.RevenuePeriod{}
+	if err := NewRevenueRepository(db).GetRevenuePeriods(f.ctx, &periods, entity.RevenueQuery{
+		From:        fixturePaidDate,
+		To:          refund.RefundedAt,
+		Granularity: entity.GranularityMonth,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(periods) != 2 {
+		t.Fatalf("GetRevenuePeriods returned %d periods, want 2", len(periods))
+	}
+	if periods[0].Revenue != 1000 || periods[0].Refunded != 0 {
+		t.Fatalf("January = %+v, want revenue 1000", periods[0])
+	}
+	if periods[1].Revenue != -300 || periods[1].Refunded != 300 {
+		t.Fatalf("February = %+v, want revenue -300 after refunding 300", periods[1])
+	}
+}
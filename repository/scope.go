@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+// bun only runs BeforeSelect hooks from Scan, so Count and Exists would skip
+// the organization filter that tenant-owned models add there. scopedCount and
+// scopedExists run the hook first.
+
+func scopedCount(ctx context.Context, q *bun.SelectQuery) (int, error) {
+	if err := beforeSelect(ctx, q); err != nil {
+		return 0, err
+	}
+	return q.Count(ctx)
+}
+
+func scopedExists(ctx context.Context, q *bun.SelectQuery) (bool, error) {
+	if err := beforeSelect(ctx, q); err != nil {
+		return false, err
+	}
+	return q.Exists(ctx)
+}
+
+func beforeSelect(ctx context.Context, q *bun.SelectQuery) error {
+	tableModel, ok := q.GetModel().(bun.TableModel)
+	if !ok {
+		return nil
+	}
+	if hook, ok := tableModel.Table().ZeroIface.(bun.BeforeSelectHook); ok {
+		return hook.BeforeSelect(ctx, q)
+	}
+	return nil
+}
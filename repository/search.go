@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"next-learn-go/entity"
+
+	"github.com/uptrace/bun"
+)
+
+type SearchRepository interface {
+	SearchCustomers(ctx context.Context, results *[]entity.CustomerSearchResult, query string, limit int) error
+	SearchInvoices(ctx context.Context, results *[]entity.InvoiceSearchResult, query string, limit int) error
+}
+
+type searchRepository struct {
+	db *bun.DB
+}
+
+func NewSearchRepository(db *bun.DB) SearchRepository {
+	return &searchRepository{db}
+}
+
+// 検索条件と関連度の式。?0 が検索語になる。
+// 全文検索 (tsvector) で語に一致したものと、pg_trgm の単語類似度で
+// 綴りの近いものの両方を拾い、どちらも GIN インデックスを使える形にしている
+const (
+	customerMatch = "(c.search_vector @@ websearch_to_tsquery('simple', ?0) OR ?0 <% c.name OR ?0 <% c.email)"
+	customerScore = "ts_rank(c.search_vector, websearch_to_tsquery('simple', ?0)) + greatest(word_similarity(?0, c.name), word_similarity(?0, c.email))"
+
+	invoiceCustomerMatch = "(customer.search_vector @@ websearch_to_tsquery('simple', ?0) OR ?0 <% customer.name OR ?0 <% customer.email)"
+	invoiceCustomerScore = "ts_rank(customer.search_vector, websearch_to_tsquery('simple', ?0)) + greatest(word_similarity(?0, customer.name), word_similarity(?0, customer.email))"
+	invoiceItemMatch     = "(ii.search_vector @@ websearch_to_tsquery('simple', ?0) OR ?0 <% ii.description)"
+	invoiceItemScore     = "ts_rank(ii.search_vector, websearch_to_tsquery('simple', ?0)) + word_similarity(?0, ii.description)"
+)
+
+func (sr *searchRepository) SearchCustomers(ctx context.Context, results *[]entity.CustomerSearchResult, query string, limit int) error {
+	q := sr.db.NewSelect().
+		Model((*entity.Customer)(nil)).
+		Column("c.id", "c.name", "c.email", "c.image_url").
+		ColumnExpr(customerScore+" AS score", query).
+		Where(customerMatch, query).
+		OrderExpr("score DESC, c.name ASC").
+		Limit(limit)
+	if err := beforeSelect(ctx, q); err != nil {
+		return translateError(err, "customer")
+	}
+	if err := q.Scan(ctx, results); err != nil {
+		return translateError(err, "customer")
+	}
+	return nil
+}
+
+// SearchInvoices finds invoices by their customer or their line items. A
+// match on the customer counts half, so that the customer itself ranks above
+// each of its invoices.
+func (sr *searchRepository) SearchInvoices(ctx context.Context, results *[]entity.InvoiceSearchResult, query string, limit int) error {
+	q := sr.db.NewSelect().
+		Model((*entity.Invoice)(nil)).
+		Column("i.id", "i.amount", "i.status", "i.date").
+		ColumnExpr("customer.name AS customer_name, customer.email AS customer_email").
+		ColumnExpr("coalesce((SELECT string_agg(ii.description, ' / ' ORDER BY ii.position) FROM invoice_items AS ii WHERE ii.invoice_id = i.id AND "+invoiceItemMatch+"), '') AS matched_items", query).
+		ColumnExpr("greatest(CASE WHEN "+invoiceCustomerMatch+" THEN ("+invoiceCustomerScore+") / 2 ELSE 0 END, "+
+			"coalesce((SELECT max("+invoiceItemScore+") FROM invoice_items AS ii WHERE ii.invoice_id = i.id AND "+invoiceItemMatch+"), 0)) AS score", query).
+		Join("JOIN customers AS customer ON customer.id = i.customer_id").
+		WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.Where(invoiceCustomerMatch, query).
+				WhereOr("EXISTS (SELECT 1 FROM invoice_items AS ii WHERE ii.invoice_id = i.id AND "+invoiceItemMatch+")", query)
+		}).
+		OrderExpr("score DESC, i.date DESC, i.id DESC").
+		Limit(limit)
+	if err := beforeSelect(ctx, q); err != nil {
+		return translateError(err, "invoice")
+	}
+	if err := q.Scan(ctx, results); err != nil {
+		return translateError(err, "invoice")
+	}
+	return nil
+}
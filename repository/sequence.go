@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"context"
+	"next-learn-go/entity"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// nextSequenceValue allocates the next number of the organization's sequence
+// name. The sequence row stays locked until tx ends, so concurrent callers
+// take numbers one after another and a rolled back transaction hands its
+// number to the next caller instead of leaving a gap.
+func nextSequenceValue(ctx context.Context, tx bun.Tx, organizationId uuid.UUID, name string) (int64, error) {
+	sequence := entity.NumberSequence{
+		OrganizationId: organizationId,
+		Name:           name,
+		LastValue:      1,
+	}
+	if _, err := tx.NewInsert().
+		Model(&sequence).
+		On("CONFLICT (organization_id, name) DO UPDATE").
+		Set("last_value = ns.last_value + 1").
+		Returning("last_value").
+		Exec(ctx); err != nil {
+		return 0, translateError(err, "number sequence")
+	}
+	return sequence.LastValue, nil
+}
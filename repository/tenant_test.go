@@ -0,0 +1,471 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"next-learn-go/apperror"
+	"next-learn-go/entity"
+	"next-learn-go/infrastructure/database/migration"
+	"next-learn-go/tenant"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+)
+
+// 2 つの組織に同じ名前の顧客と請求書を作り、どの経路からも他の組織の行が
+// 見えないことを確かめる。日付は既存のデータと混ざらないよう過去に置く
+var (
+	fixturePaidDate      = time.Date(2001, 1, 15, 0, 0, 0, 0, time.UTC)
+	fixturePendingDate   = time.Date(2001, 3, 1, 0, 0, 0, 0, time.UTC)
+	fixtureRecurringDate = time.Date(2001, 6, 1, 0, 0, 0, 0, time.UTC)
+)
+
+const fixtureCustomerName = "Zebulon Quartermaine"
+
+type tenantFixture struct {
+	ctx              context.Context
+	organizationId   uuid.UUID
+	customer         entity.Customer
+	paid             entity.Invoice
+	pending          entity.Invoice
+	recurringInvoice entity.RecurringInvoice
+}
+
+// openTestDB connects to TEST_DATABASE_URL and migrates it. The tests write
+// to every organization through the system paths, so it must be a database
+// that only tests use.
+func openTestDB(t *testing.T) *bun.DB {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	sqlDB, err := sql.Open("postgres", url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := bun.NewDB(sqlDB, pgdialect.New())
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := migration.NewMigrator(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := migrator.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func seedTenant(t *testing.T, db *bun.DB, name string, amount int) tenantFixture {
+	t.Helper()
+	organization := entity.Organization{ID: uuid.New(), Name: "tenant test " + name}
+	if _, err := db.NewInsert().Model(&organization).Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		// 組織を消すと、その組織の行はすべて連鎖して消える
+		if _, err := db.NewDelete().
+			Model((*entity.Organization)(nil)).
+			Where("id=?", organization.ID).
+			Exec(context.Background()); err != nil {
+			t.Error(err)
+		}
+	})
+
+	f := tenantFixture{
+		ctx:            tenant.WithOrganization(context.Background(), organization.ID),
+		organizationId: organization.ID,
+	}
+	f.customer = entity.Customer{
+		Name:         fixtureCustomerName,
+		Email:        "zebulon@" + name + ".example.com",
+		PaymentTerms: entity.PaymentTermsNet30,
+	}
+	if err := NewCustomerRepository(db).CreateCustomer(f.ctx, &f.customer); err != nil {
+		t.Fatal(err)
+	}
+
+	ir := NewInvoiceRepository(db, entity.DefaultInvoiceNumbering())
+	f.paid = fixtureInvoice(f.customer.ID, entity.InvoiceStatusPaid, fixturePaidDate, amount)
+	f.paid.Payments = []entity.Payment{{
+		Amount:     amount,
+		Method:     entity.PaymentMethodOther,
+		ReceivedAt: fixturePaidDate,
+	}}
+	if err := ir.CreateInvoice(f.ctx, &f.paid); err != nil {
+		t.Fatal(err)
+	}
+	f.pending = fixtureInvoice(f.customer.ID, entity.InvoiceStatusPending, fixturePendingDate, amount)
+	if err := ir.CreateInvoice(f.ctx, &f.pending); err != nil {
+		t.Fatal(err)
+	}
+
+	f.recurringInvoice = entity.RecurringInvoice{
+		CustomerId:  f.customer.ID,
+		Description: "Quartermaine retainer",
+		Amount:      amount,
+		Frequency:   entity.RecurrenceMonthly,
+		DayOfMonth:  fixtureRecurringDate.Day(),
+		StartDate:   fixtureRecurringDate,
+		NextDate:    bun.NullTime{Time: fixtureRecurringDate},
+	}
+	if err := NewRecurringInvoiceRepository(db, entity.DefaultInvoiceNumbering()).CreateRecurringInvoice(f.ctx, &f.recurringInvoice); err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func fixtureInvoice(customerId uuid.UUID, status string, date time.Time, amount int) entity.Invoice {
+	return entity.Invoice{
+		CustomerId:   customerId,
+		Subtotal:     amount,
+		Amount:       amount,
+		Status:       status,
+		Date:         date,
+		PaymentTerms: entity.PaymentTermsNet30,
+		DueDate:      date.AddDate(0, 0, 30),
+		Items: []entity.InvoiceItem{{
+			Description: "Quartermaine retainer",
+			Quantity:    1,
+			UnitPrice:   amount,
+			Subtotal:    amount,
+			Total:       amount,
+		}},
+	}
+}
+
+func TestTenantIsolation(t *testing.T) {
+	db := openTestDB(t)
+	a := seedTenant(t, db, "a", 1000)
+	b := seedTenant(t, db, "b", 5000)
+
+	ir := NewInvoiceRepository(db, entity.DefaultInvoiceNumbering())
+	cr := NewCustomerRepository(db)
+
+	t.Run("select by id", func(t *testing.T) {
+		invoice := entity.Invoice{}
+		if err := ir.GetInvoiceById(a.ctx, &invoice, b.paid.ID); !apperror.Is(err, apperror.KindNotFound) {
+			t.Fatalf("GetInvoiceById of another organization's invoice: got %v, want not found", err)
+		}
+		customer := entity.Customer{}
+		if err := cr.GetCustomerById(a.ctx, &customer, b.customer.ID); !apperror.Is(err, apperror.KindNotFound) {
+			t.Fatalf("GetCustomerById of another organization's customer: got %v, want not found", err)
+		}
+	})
+
+	t.Run("select by number", func(t *testing.T) {
+		// 連番は組織ごとなので、両方の組織に同じ番号の請求書がある
+		if a.paid.Number != b.paid.Number {
+			t.Fatalf("numbers differ: %q and %q", a.paid.Number, b.paid.Number)
+		}
+		invoice := entity.Invoice{}
+		if err := ir.GetInvoiceByNumber(a.ctx, &invoice, b.paid.Number); err != nil {
+			t.Fatal(err)
+		}
+		if invoice.ID != a.paid.ID {
+			t.Fatalf("GetInvoiceByNumber returned invoice %s, want %s", invoice.ID, a.paid.ID)
+		}
+		if len(invoice.Payments) != 1 || invoice.Payments[0].OrganizationId != a.organizationId {
+			t.Fatalf("GetInvoiceByNumber loaded payments %+v", invoice.Payments)
+		}
+	})
+
+	t.Run("select lists", func(t *testing.T) {
+		invoices := []entity.Invoice{}
+		if err := ir.GetFilteredInvoices(a.ctx, &invoices, entity.InvoiceFilter{Query: fixtureCustomerName}, 0, 100); err != nil {
+			t.Fatal(err)
+		}
+		assertInvoices(t, invoices, a, b)
+
+		invoices = []entity.Invoice{}
+		if _, err := ir.GetLatestInvoicesPage(a.ctx, &invoices, entity.Cursor{}, 100); err != nil {
+			t.Fatal(err)
+		}
+		assertInvoices(t, invoices, a, b)
+
+		invoices = []entity.Invoice{}
+		if err := ir.GetFilteredInvoices(a.ctx, &invoices, entity.InvoiceFilter{CustomerId: b.customer.ID}, 0, 100); err != nil {
+			t.Fatal(err)
+		}
+		if len(invoices) != 0 {
+			t.Fatalf("filtering by another organization's customer returned %d invoices", len(invoices))
+		}
+	})
+
+	t.Run("count", func(t *testing.T) {
+		count, err := ir.GetInvoiceCount(a.ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != 2 {
+			t.Fatalf("GetInvoiceCount = %d, want 2", count)
+		}
+		count, err = cr.GetCustomerCount(a.ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != 1 {
+			t.Fatalf("GetCustomerCount = %d, want 1", count)
+		}
+		count, err = ir.GetInvoicesPages(a.ctx, entity.InvoiceFilter{Query: fixtureCustomerName}, 0, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != 2 {
+			t.Fatalf("GetInvoicesPages = %d, want 2", count)
+		}
+		counts, err := ir.CountInvoicesByStatus(a.ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if counts[entity.InvoiceStatusPaid] != 1 || counts[entity.InvoiceStatusPending] != 1 {
+			t.Fatalf("CountInvoicesByStatus = %v, want one paid and one pending", counts)
+		}
+	})
+
+	t.Run("exists", func(t *testing.T) {
+		// bun は Exists で BeforeSelect を呼ばないため、scopedExists が絞り込みを付ける
+		q := func() *bun.SelectQuery {
+			return db.NewSelect().Model((*entity.Invoice)(nil)).Where("i.id=?", b.paid.ID)
+		}
+		exists, err := scopedExists(a.ctx, q())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if exists {
+			t.Fatal("scopedExists found another organization's invoice")
+		}
+		exists, err = scopedExists(b.ctx, q())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !exists {
+			t.Fatal("scopedExists did not find the organization's own invoice")
+		}
+	})
+
+	t.Run("write", func(t *testing.T) {
+		if err := ir.UpdateInvoiceStatus(a.ctx, b.pending.ID, entity.InvoiceStatusPending, entity.InvoiceStatusVoid); err == nil {
+			t.Fatal("UpdateInvoiceStatus changed another organization's invoice")
+		}
+		if err := ir.DeleteInvoice(a.ctx, b.pending.ID); !apperror.Is(err, apperror.KindNotFound) {
+			t.Fatalf("DeleteInvoice of another organization's invoice: got %v, want not found", err)
+		}
+		if err := cr.DeleteCustomer(a.ctx, b.customer.ID); !apperror.Is(err, apperror.KindNotFound) {
+			t.Fatalf("DeleteCustomer of another organization's customer: got %v, want not found", err)
+		}
+		recurringInvoice := a.recurringInvoice
+		recurringInvoice.ID = uuid.Nil
+		recurringInvoice.CustomerId = b.customer.ID
+		if err := NewRecurringInvoiceRepository(db, entity.DefaultInvoiceNumbering()).CreateRecurringInvoice(a.ctx, &recurringInvoice); err == nil {
+			t.Fatal("CreateRecurringInvoice billed another organization's customer")
+		}
+	})
+
+	t.Run("filtered customers", func(t *testing.T) {
+		customers := []entity.Customer{}
+		if err := cr.GetFilteredCustomers(a.ctx, &customers, fixtureCustomerName); err != nil {
+			t.Fatal(err)
+		}
+		if len(customers) != 1 || customers[0].ID != a.customer.ID {
+			t.Fatalf("GetFilteredCustomers returned %+v, want only %s", customers, a.customer.ID)
+		}
+		if customers[0].TotalInvoices != 2 || customers[0].TotalPaid != 1000 || customers[0].TotalPending != 1000 {
+			t.Fatalf("GetFilteredCustomers totals = %+v", customers[0])
+		}
+	})
+
+	t.Run("revenue", func(t *testing.T) {
+		periods := []entity.RevenuePeriod{}
+		if err := NewRevenueRepository(db).GetRevenuePeriods(a.ctx, &periods, entity.RevenueQuery{
+			From:        fixturePaidDate,
+			To:          fixturePaidDate,
+			Granularity: entity.GranularityMonth,
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if len(periods) != 1 || periods[0].Revenue != 1000 {
+			t.Fatalf("GetRevenuePeriods = %+v, want 1000 in one period", periods)
+		}
+	})
+
+	t.Run("search", func(t *testing.T) {
+		sr := NewSearchRepository(db)
+		customers := []entity.CustomerSearchResult{}
+		if err := sr.SearchCustomers(a.ctx, &customers, fixtureCustomerName, 100); err != nil {
+			t.Fatal(err)
+		}
+		if len(customers) != 1 || customers[0].ID != a.customer.ID {
+			t.Fatalf("SearchCustomers returned %+v, want only %s", customers, a.customer.ID)
+		}
+		invoices := []entity.InvoiceSearchResult{}
+		if err := sr.SearchInvoices(a.ctx, &invoices, "retainer", 100); err != nil {
+			t.Fatal(err)
+		}
+		ids := []uuid.UUID{}
+		for _, v := range invoices {
+			ids = append(ids, v.ID)
+		}
+		if len(ids) != 2 || !slices.Contains(ids, a.paid.ID) || !slices.Contains(ids, a.pending.ID) {
+			t.Fatalf("SearchInvoices returned %v, want %s and %s", ids, a.paid.ID, a.pending.ID)
+		}
+	})
+
+	t.Run("missing organization", func(t *testing.T) {
+		// 組織も WithoutScope もない ctx では全件を返さずに失敗する
+		if _, err := ir.GetInvoiceCount(context.Background()); !errors.Is(err, tenant.ErrMissingOrganization) {
+			t.Fatalf("GetInvoiceCount without organization: got %v", err)
+		}
+		invoices := []entity.Invoice{}
+		if err := ir.GetFilteredInvoices(context.Background(), &invoices, entity.InvoiceFilter{}, 0, 100); !errors.Is(err, tenant.ErrMissingOrganization) {
+			t.Fatalf("GetFilteredInvoices without organization: got %v", err)
+		}
+		if err := cr.CreateCustomer(context.Background(), &entity.Customer{Name: "nobody", Email: "nobody@example.com", PaymentTerms: entity.PaymentTermsNet30}); !errors.Is(err, tenant.ErrMissingOrganization) {
+			t.Fatalf("CreateCustomer without organization: got %v", err)
+		}
+	})
+
+	t.Run("system tasks", func(t *testing.T) {
+		system := tenant.WithoutScope(context.Background())
+
+		counts, err := ir.CountInvoicesByStatus(system)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if counts[entity.InvoiceStatusPaid] < 2 {
+			t.Fatalf("CountInvoicesByStatus across organizations = %v, want both paid invoices", counts)
+		}
+
+		// 延滞への移行は組織をまたぐが、履歴はそれぞれの請求書の組織に記録する
+		if _, err := ir.MarkInvoicesOverdue(system, []string{entity.InvoiceStatusPending}); err != nil {
+			t.Fatal(err)
+		}
+		for _, f := range []tenantFixture{a, b} {
+			invoice := entity.Invoice{}
+			if err := ir.GetInvoiceById(f.ctx, &invoice, f.pending.ID); err != nil {
+				t.Fatal(err)
+			}
+			if invoice.Status != entity.InvoiceStatusOverdue {
+				t.Fatalf("invoice %s is %s, want overdue", invoice.ID, invoice.Status)
+			}
+			last := invoice.StatusHistory[len(invoice.StatusHistory)-1]
+			if last.ToStatus != entity.InvoiceStatusOverdue || last.OrganizationId != f.organizationId {
+				t.Fatalf("last status change of %s = %+v", invoice.ID, last)
+			}
+		}
+
+		rr := NewRecurringInvoiceRepository(db, entity.DefaultInvoiceNumbering())
+		due := []entity.RecurringInvoice{}
+		if err := rr.GetDueRecurringInvoices(system, &due, fixtureRecurringDate); err != nil {
+			t.Fatal(err)
+		}
+		dueIds := []uuid.UUID{}
+		for _, v := range due {
+			dueIds = append(dueIds, v.ID)
+		}
+		if !slices.Contains(dueIds, a.recurringInvoice.ID) || !slices.Contains(dueIds, b.recurringInvoice.ID) {
+			t.Fatalf("GetDueRecurringInvoices across organizations = %v, want both recurring invoices", dueIds)
+		}
+
+		generate := func(recurringInvoice entity.RecurringInvoice) (entity.Invoice, bun.NullTime, error) {
+			invoice := fixtureInvoice(recurringInvoice.CustomerId, entity.InvoiceStatusPending, recurringInvoice.NextDate.Time, recurringInvoice.Amount)
+			invoice.RecurringInvoiceId = uuid.NullUUID{UUID: recurringInvoice.ID, Valid: true}
+			invoice.RecurrenceDate = recurringInvoice.NextDate
+			return invoice, bun.NullTime{}, nil
+		}
+		// 生成は組織ごとの ctx で行うため、他の組織のひな形には触れない
+		count, err := rr.GenerateInvoices(a.ctx, b.recurringInvoice.ID, fixtureRecurringDate, generate)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != 0 {
+			t.Fatalf("GenerateInvoices created %d invoices from another organization's recurring invoice", count)
+		}
+		count, err = rr.GenerateInvoices(b.ctx, b.recurringInvoice.ID, fixtureRecurringDate, generate)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != 1 {
+			t.Fatalf("GenerateInvoices created %d invoices, want 1", count)
+		}
+		for _, want := range []struct {
+			f     tenantFixture
+			count int
+		}{{a, 2}, {b, 3}} {
+			count, err := ir.GetInvoiceCount(want.f.ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if count != want.count {
+				t.Fatalf("organization %s has %d invoices, want %d", want.f.organizationId, count, want.count)
+			}
+		}
+	})
+}
+
+func assertInvoices(t *testing.T, invoices []entity.Invoice, own, other tenantFixture) {
+	t.Helper()
+	seen := 0
+	for _, v := range invoices {
+		if v.OrganizationId != own.organizationId {
+			t.Fatalf("invoice %s of organization %s leaked into %s", v.ID, v.OrganizationId, own.organizationId)
+		}
+		if v.ID == other.paid.ID || v.ID == other.pending.ID {
+			t.Fatalf("invoice %s of the other organization was returned", v.ID)
+		}
+		if v.ID == own.paid.ID || v.ID == own.pending.ID {
+			seen++
+		}
+	}
+	if seen != 2 {
+		t.Fatalf("found %d of the organization's 2 invoices", seen)
+	}
+}
+
+// TestBeforeSelect checks the SQL that scopedCount and scopedExists run,
+// without a database.
+func TestBeforeSelect(t *testing.T) {
+	sqlDB, err := sql.Open("postgres", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := bun.NewDB(sqlDB, pgdialect.New())
+	defer db.Close()
+
+	organizationId := uuid.New()
+	for _, model := range []any{
+		(*entity.Invoice)(nil),
+		(*entity.Customer)(nil),
+		(*entity.Payment)(nil),
+		(*entity.CreditNote)(nil),
+		(*entity.RecurringInvoice)(nil),
+	} {
+		q := db.NewSelect().Model(model)
+		if err := beforeSelect(tenant.WithOrganization(context.Background(), organizationId), q); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(q.String(), ".organization_id = '"+organizationId.String()+"'") {
+			t.Errorf("%T is not scoped: %s", model, q.String())
+		}
+
+		q = db.NewSelect().Model(model)
+		if err := beforeSelect(tenant.WithoutScope(context.Background()), q); err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(q.String(), " WHERE ") {
+			t.Errorf("%T is scoped without an organization: %s", model, q.String())
+		}
+
+		if err := beforeSelect(context.Background(), db.NewSelect().Model(model)); !errors.Is(err, tenant.ErrMissingOrganization) {
+			t.Errorf("%T without organization: got %v", model, err)
+		}
+	}
+}
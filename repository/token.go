@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"next-learn-go/apperror"
+	"next-learn-go/entity"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+type TokenRepository interface {
+	GetRefreshTokenByHash(ctx context.Context, token *entity.RefreshToken, tokenHash string) error
+	CreateRefreshToken(ctx context.Context, token *entity.RefreshToken) error
+	RotateRefreshToken(ctx context.Context, oldTokenId uuid.UUID, newToken *entity.RefreshToken) error
+	RevokeTokenFamily(ctx context.Context, familyId uuid.UUID) error
+	GetActiveSessionRole(ctx context.Context, familyId, organizationId, userId uuid.UUID) (string, error)
+}
+
+type tokenRepository struct {
+	db *bun.DB
+}
+
+func NewTokenRepository(db *bun.DB) TokenRepository {
+	return &tokenRepository{db}
+}
+
+func (tr *tokenRepository) GetRefreshTokenByHash(ctx context.Context, token *entity.RefreshToken, tokenHash string) error {
+	if err := tr.db.NewSelect().
+		Model(token).
+		Where("token_hash=?", tokenHash).
+		Scan(ctx); err != nil {
+		return translateError(err, "refresh token")
+	}
+	return nil
+}
+
+func (tr *tokenRepository) CreateRefreshToken(ctx context.Context, token *entity.RefreshToken) error {
+	if _, err := tr.db.NewInsert().Model(token).Exec(ctx); err != nil {
+		return translateError(err, "refresh token")
+	}
+	return nil
+}
+
+func (tr *tokenRepository) RotateRefreshToken(ctx context.Context, oldTokenId uuid.UUID, newToken *entity.RefreshToken) error {
+	return tr.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.NewInsert().Model(newToken).Exec(ctx); err != nil {
+			return translateError(err, "refresh token")
+		}
+		// 同じトークンで同時にリフレッシュされた場合は片方だけが更新に成功する
+		result, err := tx.NewUpdate().
+			Model((*entity.RefreshToken)(nil)).
+			Set("replaced_by=?", newToken.ID).
+			Where("id=?", oldTokenId).
+			Where("replaced_by IS NULL").
+			Where("revoked_at IS NULL").
+			Exec(ctx)
+		if err != nil {
+			return translateError(err, "refresh token")
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return translateError(err, "refresh token")
+		}
+		if rowsAffected < 1 {
+			return apperror.Conflict("refresh token has already been used")
+		}
+		return nil
+	})
+}
+
+func (tr *tokenRepository) RevokeTokenFamily(ctx context.Context, familyId uuid.UUID) error {
+	if _, err := tr.db.NewUpdate().
+		Model((*entity.RefreshToken)(nil)).
+		Set("revoked_at=?", time.Now()).
+		Where("family_id=?", familyId).
+		Where("revoked_at IS NULL").
+		Exec(ctx); err != nil {
+		return translateError(err, "refresh token")
+	}
+	return nil
+}
+
+// GetActiveSessionRole returns the user's current role in the organization,
+// or an empty role when the session has been revoked or has expired or the
+// user is no longer a member.
+func (tr *tokenRepository) GetActiveSessionRole(ctx context.Context, familyId, organizationId, userId uuid.UUID) (string, error) {
+	roles := []string{}
+	if err := tr.db.NewSelect().
+		Model((*entity.OrganizationMember)(nil)).
+		Column("om.role").
+		Where("om.organization_id=?", organizationId).
+		Where("om.user_id=?", userId).
+		Where("EXISTS (?)", tr.db.NewSelect().
+			Model((*entity.RefreshToken)(nil)).
+			Where("rt.family_id=?", familyId).
+			Where("rt.revoked_at IS NULL").
+			Where("rt.expires_at > ?", time.Now())).
+		Scan(ctx, &roles); err != nil {
+		return "", translateError(err, "refresh token")
+	}
+	if len(roles) == 0 {
+		return "", nil
+	}
+	return roles[0], nil
+}
@@ -4,13 +4,14 @@ import (
 	"context"
 	"next-learn-go/entity"
 
+	"github.com/google/uuid"
 	"github.com/uptrace/bun"
 )
 
 type UserRepository interface {
 	GetUserByEmail(ctx context.Context, user *entity.User, email string) error
-	CreateUser(ctx context.Context, user *entity.User) error
-	GetUserById(ctx context.Context, user *entity.User, userId uint) error
+	CreateUser(ctx context.Context, user *entity.User, organization *entity.Organization) error
+	GetUserById(ctx context.Context, user *entity.User, userId uuid.UUID) error
 }
 
 type userRepository struct {
@@ -26,24 +27,38 @@ func (ur *userRepository) GetUserByEmail(ctx context.Context, user *entity.User,
 		Model(user).
 		Where("email=?", email).
 		Scan(ctx); err != nil {
-		return err
+		return translateError(err, "user")
 	}
 	return nil
 }
 
-func (ur *userRepository) CreateUser(ctx context.Context, user *entity.User) error {
-	if _, err := ur.db.NewInsert().Model(user).Exec(ctx); err != nil {
-		return err
-	}
-	return nil
+func (ur *userRepository) CreateUser(ctx context.Context, user *entity.User, organization *entity.Organization) error {
+	// ユーザーと所属組織、管理者としてのメンバーシップをまとめて作成する
+	return ur.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.NewInsert().Model(user).Exec(ctx); err != nil {
+			return translateError(err, "user")
+		}
+		if _, err := tx.NewInsert().Model(organization).Exec(ctx); err != nil {
+			return translateError(err, "organization")
+		}
+		member := entity.OrganizationMember{
+			OrganizationId: organization.ID,
+			UserId:         user.ID,
+			Role:           entity.RoleAdmin,
+		}
+		if _, err := tx.NewInsert().Model(&member).Exec(ctx); err != nil {
+			return translateError(err, "organization member")
+		}
+		return nil
+	})
 }
 
-func (ur *userRepository) GetUserById(ctx context.Context, user *entity.User, userId uint) error {
+func (ur *userRepository) GetUserById(ctx context.Context, user *entity.User, userId uuid.UUID) error {
 	if err := ur.db.NewSelect().
 		Model(user).
 		Where("id=?", userId).
 		Scan(ctx); err != nil {
-		return err
+		return translateError(err, "user")
 	}
 	return nil
 }
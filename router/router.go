@@ -1,74 +1,239 @@
 package router
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"next-learn-go/controller"
 	"next-learn-go/controller/middleware"
+	"next-learn-go/entity"
+	"next-learn-go/infrastructure/database/migration"
+	"next-learn-go/infrastructure/health"
+	"next-learn-go/infrastructure/metrics"
+	"next-learn-go/infrastructure/pdf"
+	"next-learn-go/lifecycle"
+	"next-learn-go/logger"
 	"next-learn-go/repository"
+	"next-learn-go/tenant"
 	"next-learn-go/usecase"
 	"next-learn-go/validator"
+	"os"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/uptrace/bun"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/labstack/echo/otelecho"
 )
 
 func NewRouter(
 	db *bun.DB,
+	lc lifecycle.Lifecycle,
+	migrator migration.Migrator,
+	invoiceNumbering entity.InvoiceNumbering,
 ) *echo.Echo {
+	appMetrics := metrics.NewMetrics()
+	appMetrics.AddDBStats(db.DB)
+	db.AddQueryHook(appMetrics.QueryHook())
+
 	e := echo.New()
+	e.HideBanner = true
+	e.HidePort = true
+	e.HTTPErrorHandler = controller.HTTPErrorHandler
+	e.Use(otelecho.Middleware("next-learn-go", otelecho.WithSkipper(func(c echo.Context) bool {
+		// プローブとスクレイプはトレースしない
+		switch c.Path() {
+		case "/healthz", "/readyz", "/metrics":
+			return true
+		}
+		return false
+	})))
+	e.Use(middleware.RequestIdMiddleware())
+	e.Use(middleware.MetricsMiddleware(appMetrics))
+	e.Use(middleware.AccessLogMiddleware())
 	e.Use(middleware.CorsMiddleware())
-	jwtMiddleware := middleware.JwtMiddleware()
+	e.Use(middleware.TimeoutMiddleware(middleware.TimeoutConfigFromEnv(map[string]time.Duration{
+		// PDF の生成は通常の API より時間がかかる
+		"GET /invoices/:invoiceId/pdf": 30 * time.Second,
+	})))
 
 	userValidator := validator.NewUserValidator()
 	invoiceValidator := validator.NewInvoiceValidator()
+	customerValidator := validator.NewCustomerValidator()
+	organizationValidator := validator.NewOrganizationValidator()
+	revenueValidator := validator.NewRevenueValidator()
+	searchValidator := validator.NewSearchValidator()
+	paymentValidator := validator.NewPaymentValidator()
+	creditNoteValidator := validator.NewCreditNoteValidator()
+	recurringInvoiceValidator := validator.NewRecurringInvoiceValidator()
 
 	userRepository := repository.NewUserRepository(db)
-	invoiceRepository := repository.NewInvoiceRepository(db)
+	tokenRepository := repository.NewTokenRepository(db)
+	invoiceRepository := repository.NewInvoiceRepository(db, invoiceNumbering)
 	revenueRepository := repository.NewRevenueRepository(db)
 	customerRepository := repository.NewCustomerRepository(db)
+	organizationRepository := repository.NewOrganizationRepository(db)
+	searchRepository := repository.NewSearchRepository(db)
+	paymentRepository := repository.NewPaymentRepository(db)
+	creditNoteRepository := repository.NewCreditNoteRepository(db)
+	recurringInvoiceRepository := repository.NewRecurringInvoiceRepository(db, invoiceNumbering)
+
+	invoiceRenderer := pdf.NewInvoiceRenderer(pdf.BrandingFromEnv())
+
+	userUseCase := usecase.NewUserUseCase(userRepository, organizationRepository, tokenRepository, userValidator)
+	invoiceUseCase := usecase.NewInvoiceUseCase(invoiceRepository, customerRepository, invoiceValidator, invoiceRenderer)
+	revenueUseCase := usecase.NewRevenueUseCase(revenueRepository, revenueValidator)
+	customerUseCase := usecase.NewCustomerUseCase(customerRepository, customerValidator)
+	organizationUseCase := usecase.NewOrganizationUseCase(organizationRepository, userRepository, organizationValidator)
+	searchUseCase := usecase.NewSearchUseCase(searchRepository, searchValidator)
+	paymentUseCase := usecase.NewPaymentUseCase(paymentRepository, invoiceRepository, paymentValidator)
+	creditNoteUseCase := usecase.NewCreditNoteUseCase(creditNoteRepository, invoiceRepository, creditNoteValidator)
+	recurringInvoiceUseCase := usecase.NewRecurringInvoiceUseCase(recurringInvoiceRepository, customerRepository, recurringInvoiceValidator)
+
+	jwtMiddleware := middleware.JwtMiddleware(userUseCase)
+
+	// 請求書の件数は組織をまたいで集計する
+	appMetrics.AddInvoiceStatusGauge(func(ctx context.Context) (map[string]int, error) {
+		return invoiceRepository.CountInvoicesByStatus(tenant.WithoutScope(ctx))
+	})
+
+	// 期日を過ぎた請求書は組織をまたいで定期的に延滞へ移す
+	lc.AddWorker("overdue-invoices", lifecycle.Periodic(lifecycle.DurationFromEnv("OVERDUE_CHECK_INTERVAL", time.Hour), func(ctx context.Context) error {
+		count, err := invoiceUseCase.MarkOverdueInvoices(tenant.WithoutScope(ctx))
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			logger.FromContext(ctx).Info("invoices marked overdue", "count", count)
+		}
+		return nil
+	}))
 
-	userUseCase := usecase.NewUserUseCase(userRepository, userValidator)
-	invoiceUseCase := usecase.NewInvoiceUseCase(invoiceRepository, invoiceValidator)
-	revenueUseCase := usecase.NewRevenueUseCase(revenueRepository)
-	customerUseCase := usecase.NewCustomerUseCase(customerRepository)
+	// 定期請求の請求書を組織をまたいで作成する。失敗したひな形は次の実行で再試行される
+	lc.AddWorker("recurring-invoices", lifecycle.Periodic(lifecycle.DurationFromEnv("RECURRING_INVOICE_INTERVAL", time.Hour), func(ctx context.Context) error {
+		count, err := recurringInvoiceUseCase.GenerateRecurringInvoices(tenant.WithoutScope(ctx))
+		if count > 0 {
+			logger.FromContext(ctx).Info("recurring invoices created", "count", count)
+		}
+		return err
+	}))
 
+	healthRegistry := health.NewRegistryFromEnv()
+	healthRegistry.AddReadinessCheck("lifecycle", func(ctx context.Context) error {
+		if !lc.Ready() {
+			return errors.New("shutting down")
+		}
+		return nil
+	}, health.Uncached())
+	healthRegistry.AddReadinessCheck("database", health.DatabaseCheck(db))
+	healthRegistry.AddReadinessCheck("migrations", health.MigrationCheck(migrator))
+
+	healthController := controller.NewHealthController(healthRegistry)
+	docsController := controller.NewDocsController()
+	metricsController := controller.NewMetricsController(appMetrics, os.Getenv("METRICS_TOKEN"))
 	userController := controller.NewUserController(userUseCase)
 	invoiceController := controller.NewInvoiceController(invoiceUseCase)
 	revenueController := controller.NewRevenueController(revenueUseCase)
 	customerController := controller.NewCustomerController(customerUseCase)
+	organizationController := controller.NewOrganizationController(organizationUseCase)
+	searchController := controller.NewSearchController(searchUseCase)
+	paymentController := controller.NewPaymentController(paymentUseCase)
+	creditNoteController := controller.NewCreditNoteController(creditNoteUseCase)
+	recurringInvoiceController := controller.NewRecurringInvoiceController(recurringInvoiceUseCase)
 
 	e.GET("/", func(c echo.Context) error {
+		// シャットダウン中は新しいリクエストを受けないよう準備未完了を返す
+		if !lc.Ready() {
+			return c.String(http.StatusServiceUnavailable, "shutting down")
+		}
 		return c.String(http.StatusOK, "OK")
 	})
+	e.GET("/healthz", healthController.Live)
+	e.GET("/readyz", healthController.Ready)
+	e.GET("/metrics", metricsController.Metrics)
+	e.GET("/openapi.json", docsController.OpenAPI)
+	e.GET("/docs", docsController.SwaggerUI)
+	e.GET("/docs/*", docsController.SwaggerUIAsset)
 
 	e.POST("/register", userController.SignUp)
 	e.POST("/login", userController.LogIn)
+	e.POST("/token/refresh", userController.RefreshToken)
+	e.POST("/logout", userController.LogOut, jwtMiddleware)
+
+	readInvoices := middleware.RequirePermission(entity.PermissionInvoicesRead)
+	writeInvoices := middleware.RequirePermission(entity.PermissionInvoicesWrite)
+	deleteInvoices := middleware.RequirePermission(entity.PermissionInvoicesDelete)
+	readCustomers := middleware.RequirePermission(entity.PermissionCustomersRead)
+	writeCustomers := middleware.RequirePermission(entity.PermissionCustomersWrite)
+	deleteCustomers := middleware.RequirePermission(entity.PermissionCustomersDelete)
+	readRevenues := middleware.RequirePermission(entity.PermissionRevenuesRead)
+	manageUsers := middleware.RequirePermission(entity.PermissionUsersManage)
 
 	i := e.Group("/invoices")
 	i.Use(jwtMiddleware)
-	i.GET("/latest", invoiceController.GetLatestInvoices)
-	i.GET("/filtered", invoiceController.GetFilteredInvoices)
-	i.GET("/count", invoiceController.GetInvoiceCount)
-	i.GET("/status/count", invoiceController.GetInvoiceStatusCount)
-	i.GET("/pages", invoiceController.GetInvoicesPages)
-	i.GET("/:invoiceId", invoiceController.GetInvoiceById)
-	i.POST("", invoiceController.CreateInvoice)
-	i.PATCH("/:invoiceId", invoiceController.UpdateInvoice)
-	i.DELETE("/:invoiceId", invoiceController.DeleteInvoice)
+	i.GET("/latest", invoiceController.GetLatestInvoices, readInvoices)
+	i.GET("/filtered", invoiceController.GetFilteredInvoices, readInvoices)
+	i.GET("/count", invoiceController.GetInvoiceCount, readInvoices)
+	i.GET("/status/count", invoiceController.GetInvoiceStatusCount, readInvoices)
+	i.GET("/pages", invoiceController.GetInvoicesPages, readInvoices)
+	i.GET("/by-number/:invoiceNumber", invoiceController.GetInvoiceByNumber, readInvoices)
+	i.GET("/:invoiceId", invoiceController.GetInvoiceById, readInvoices)
+	i.GET("/:invoiceId/pdf", invoiceController.GetInvoicePdf, readInvoices)
+	i.POST("", invoiceController.CreateInvoice, writeInvoices)
+	i.PATCH("/:invoiceId", invoiceController.UpdateInvoice, writeInvoices)
+	i.POST("/:invoiceId/send", invoiceController.SendInvoice, writeInvoices)
+	i.POST("/:invoiceId/pay", paymentController.PayInvoice, writeInvoices)
+	i.POST("/:invoiceId/void", invoiceController.VoidInvoice, writeInvoices)
+	i.GET("/:invoiceId/payments", paymentController.GetPayments, readInvoices)
+	i.POST("/:invoiceId/payments", paymentController.CreatePayment, writeInvoices)
+	i.DELETE("/:invoiceId/payments/:paymentId", paymentController.DeletePayment, deleteInvoices)
+	i.POST("/:invoiceId/payments/:paymentId/refunds", paymentController.CreateRefund, writeInvoices)
+	i.GET("/:invoiceId/credit-notes", creditNoteController.GetCreditNotes, readInvoices)
+	i.POST("/:invoiceId/credit-notes", creditNoteController.CreateCreditNote, writeInvoices)
+	i.DELETE("/:invoiceId", invoiceController.DeleteInvoice, deleteInvoices)
+
+	ri := e.Group("/recurring-invoices")
+	ri.Use(jwtMiddleware)
+	ri.GET("", recurringInvoiceController.GetRecurringInvoices, readInvoices)
+	ri.GET("/:recurringInvoiceId", recurringInvoiceController.GetRecurringInvoiceById, readInvoices)
+	ri.GET("/:recurringInvoiceId/preview", recurringInvoiceController.PreviewRecurringInvoice, readInvoices)
+	ri.POST("", recurringInvoiceController.CreateRecurringInvoice, writeInvoices)
+	ri.PATCH("/:recurringInvoiceId", recurringInvoiceController.UpdateRecurringInvoice, writeInvoices)
+	ri.DELETE("/:recurringInvoiceId", recurringInvoiceController.DeleteRecurringInvoice, deleteInvoices)
 
 	r := e.Group("/revenues")
 	r.Use(jwtMiddleware)
-	r.GET("", revenueController.GetAllRevenues)
+	r.GET("", revenueController.GetAllRevenues, readRevenues)
+	r.GET("/aggregate", revenueController.GetRevenueAggregate, readRevenues)
 
 	c := e.Group("/customers")
 	c.Use(jwtMiddleware)
-	c.GET("", customerController.GetAllCustomers)
-	c.GET("/filtered", customerController.GetFilteredCustomers)
-	c.GET("/count", customerController.GetCustomerCount)
+	c.GET("", customerController.GetAllCustomers, readCustomers)
+	c.GET("/filtered", customerController.GetFilteredCustomers, readCustomers)
+	c.GET("/count", customerController.GetCustomerCount, readCustomers)
+	c.GET("/:customerId", customerController.GetCustomerById, readCustomers)
+	c.POST("", customerController.CreateCustomer, writeCustomers)
+	c.PATCH("/:customerId", customerController.UpdateCustomer, writeCustomers)
+	c.DELETE("/:customerId", customerController.DeleteCustomer, deleteCustomers)
+
+	// 顧客と請求書の両方を返すため、両方の閲覧権限を求める
+	e.GET("/search", searchController.Search, jwtMiddleware, readCustomers, readInvoices)
 
 	u := e.Group("/user")
 	u.Use(jwtMiddleware)
 	u.GET("", userController.GetUserById)
 	u.GET("/email", userController.GetUserByEmail)
+
+	o := e.Group("/organizations")
+	o.Use(jwtMiddleware)
+	o.GET("", organizationController.GetMyOrganizations)
+
+	m := e.Group("/organization/members")
+	m.Use(jwtMiddleware)
+	m.GET("", organizationController.GetMembers, manageUsers)
+	m.POST("", organizationController.AddMember, manageUsers)
+
+	us := e.Group("/users")
+	us.Use(jwtMiddleware)
+	us.PATCH("/:userId/role", organizationController.UpdateMemberRole, manageUsers)
 	return e
 }
@@ -0,0 +1,33 @@
+package router
+
+import (
+	"database/sql"
+	"next-learn-go/entity"
+	"next-learn-go/infrastructure/database/migration"
+	"next-learn-go/lifecycle"
+	"next-learn-go/openapi"
+	"testing"
+
+	_ "github.com/lib/pq"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+)
+
+func TestRoutesMatchOpenAPI(t *testing.T) {
+	// ルートの登録だけなので接続はしない
+	sqldb, err := sql.Open("postgres", "postgres://localhost/router_test?sslmode=disable")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := bun.NewDB(sqldb, pgdialect.New())
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := migration.NewMigrator(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := NewRouter(db, lifecycle.NewLifecycle(0), migrator, entity.DefaultInvoiceNumbering())
+	if err := openapi.CheckRoutes(e.Routes()); err != nil {
+		t.Fatal(err)
+	}
+}
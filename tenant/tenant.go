@@ -0,0 +1,89 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+var ErrMissingOrganization = errors.New("organization is missing from context")
+
+type organizationKey struct{}
+
+type unscopedKey struct{}
+
+func WithOrganization(ctx context.Context, organizationId uuid.UUID) context.Context {
+	return context.WithValue(ctx, organizationKey{}, organizationId)
+}
+
+func OrganizationId(ctx context.Context) (uuid.UUID, bool) {
+	organizationId, ok := ctx.Value(organizationKey{}).(uuid.UUID)
+	return organizationId, ok && organizationId != uuid.Nil
+}
+
+// WithoutScope marks ctx as belonging to a system task that works across
+// organizations, such as a scheduled job. Queries made with it are not
+// filtered by organization.
+func WithoutScope(ctx context.Context) context.Context {
+	return context.WithValue(ctx, unscopedKey{}, true)
+}
+
+func IsUnscoped(ctx context.Context) bool {
+	unscoped, _ := ctx.Value(unscopedKey{}).(bool)
+	return unscoped
+}
+
+// Select, Update and Delete are called from the bun hooks of tenant-owned
+// models and restrict the query to the organization in ctx. They fail closed
+// when ctx carries neither an organization nor WithoutScope.
+func Select(ctx context.Context, q *bun.SelectQuery) error {
+	organizationId, err := scope(ctx)
+	if err != nil || organizationId == uuid.Nil {
+		return err
+	}
+	q.Where("?TableAlias.organization_id = ?", organizationId)
+	return nil
+}
+
+func Update(ctx context.Context, q *bun.UpdateQuery) error {
+	organizationId, err := scope(ctx)
+	if err != nil || organizationId == uuid.Nil {
+		return err
+	}
+	q.Where("?TableAlias.organization_id = ?", organizationId)
+	return nil
+}
+
+func Delete(ctx context.Context, q *bun.DeleteQuery) error {
+	organizationId, err := scope(ctx)
+	if err != nil || organizationId == uuid.Nil {
+		return err
+	}
+	q.Where("?TableAlias.organization_id = ?", organizationId)
+	return nil
+}
+
+// Assign sets the organization of a row that is about to be inserted. System
+// tasks may insert rows for any organization as long as it is already set.
+func Assign(ctx context.Context, organizationId *uuid.UUID) error {
+	if id, ok := OrganizationId(ctx); ok {
+		*organizationId = id
+		return nil
+	}
+	if IsUnscoped(ctx) && *organizationId != uuid.Nil {
+		return nil
+	}
+	return ErrMissingOrganization
+}
+
+func scope(ctx context.Context) (uuid.UUID, error) {
+	if organizationId, ok := OrganizationId(ctx); ok {
+		return organizationId, nil
+	}
+	if IsUnscoped(ctx) {
+		return uuid.Nil, nil
+	}
+	return uuid.Nil, ErrMissingOrganization
+}
@@ -0,0 +1,97 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"next-learn-go/apperror"
+	"next-learn-go/entity"
+	"next-learn-go/repository"
+	"next-learn-go/validator"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CreditNoteUseCase interface {
+	GetCreditNotes(ctx context.Context, invoiceId uuid.UUID) ([]entity.CreditNoteResponse, error)
+	CreateCreditNote(ctx context.Context, creditNote entity.CreditNote, invoiceId uuid.UUID) (entity.CreditNoteResponse, error)
+}
+
+type creditNoteUseCase struct {
+	cr repository.CreditNoteRepository
+	ir repository.InvoiceRepository
+	cv validator.CreditNoteValidator
+}
+
+func NewCreditNoteUseCase(cr repository.CreditNoteRepository, ir repository.InvoiceRepository, cv validator.CreditNoteValidator) CreditNoteUseCase {
+	return &creditNoteUseCase{cr, ir, cv}
+}
+
+func (cu *creditNoteUseCase) GetCreditNotes(ctx context.Context, invoiceId uuid.UUID) ([]entity.CreditNoteResponse, error) {
+	ctx, span := tracer.Start(ctx, "CreditNoteUseCase.GetCreditNotes")
+	defer span.End()
+
+	// 請求書が存在しない場合に空の一覧ではなく 404 を返す
+	invoice := entity.Invoice{}
+	if err := cu.ir.GetInvoiceById(ctx, &invoice, invoiceId); err != nil {
+		return nil, err
+	}
+	return toCreditNoteResponses(invoice.CreditNotes), nil
+}
+
+func (cu *creditNoteUseCase) CreateCreditNote(ctx context.Context, creditNote entity.CreditNote, invoiceId uuid.UUID) (entity.CreditNoteResponse, error) {
+	ctx, span := tracer.Start(ctx, "CreditNoteUseCase.CreateCreditNote")
+	defer span.End()
+
+	if err := cu.cv.CreditNoteValidate(creditNote); err != nil {
+		return entity.CreditNoteResponse{}, err
+	}
+	newCreditNote := entity.CreditNote{
+		InvoiceId: invoiceId,
+		Amount:    creditNote.Amount,
+		Reason:    creditNote.Reason,
+		IssuedAt:  creditNote.IssuedAt,
+	}
+	if newCreditNote.IssuedAt.IsZero() {
+		newCreditNote.IssuedAt = time.Now()
+	}
+	if err := cu.cr.CreateCreditNote(ctx, &newCreditNote, settleCreditNote); err != nil {
+		return entity.CreditNoteResponse{}, err
+	}
+	return toCreditNoteResponse(newCreditNote), nil
+}
+
+// settleCreditNote settles an invoice after a credit note has been issued
+// against it, which must not take the credit beyond what is still owed. Money
+// already received is returned with a refund first.
+func settleCreditNote(invoice entity.Invoice, balance entity.InvoiceBalance) (string, error) {
+	switch invoice.Status {
+	case entity.InvoiceStatusDraft:
+		return "", apperror.Conflict("draft invoices cannot be credited; edit the invoice instead")
+	case entity.InvoiceStatusVoid:
+		return "", apperror.Conflict("void invoices cannot be credited")
+	}
+	if due := balance.Due(invoice.Amount); due < 0 {
+		return "", apperror.InvalidField("amount", fmt.Sprintf("credit notes would exceed the balance due by %d; refund the payment first", -due))
+	}
+	return settleInvoice(invoice, balance)
+}
+
+func toCreditNoteResponses(creditNotes []entity.CreditNote) []entity.CreditNoteResponse {
+	resCreditNotes := []entity.CreditNoteResponse{}
+	for _, v := range creditNotes {
+		resCreditNotes = append(resCreditNotes, toCreditNoteResponse(v))
+	}
+	return resCreditNotes
+}
+
+func toCreditNoteResponse(creditNote entity.CreditNote) entity.CreditNoteResponse {
+	return entity.CreditNoteResponse{
+		ID:        creditNote.ID,
+		InvoiceId: creditNote.InvoiceId,
+		Number:    creditNote.Number,
+		Amount:    creditNote.Amount,
+		Reason:    creditNote.Reason,
+		IssuedAt:  creditNote.IssuedAt,
+	}
+}
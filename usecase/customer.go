@@ -2,28 +2,38 @@ package usecase
 
 import (
 	"context"
-
 	"next-learn-go/entity"
 	"next-learn-go/repository"
+	"next-learn-go/validator"
+
+	"github.com/google/uuid"
 )
 
 type CustomerUseCase interface {
-	GetAllCustomers() ([]entity.GetAllCustomerResponse, error)
-	GetFilteredCustomers(query string) ([]entity.GetFilteredCustomerResponse, error)
-	GetCustomerCount() (int, error)
+	GetAllCustomers(ctx context.Context) ([]entity.GetAllCustomerResponse, error)
+	GetFilteredCustomers(ctx context.Context, query string) ([]entity.GetFilteredCustomerResponse, error)
+	GetCustomerCount(ctx context.Context) (int, error)
+	GetCustomerById(ctx context.Context, customerId uuid.UUID) (entity.CustomerResponse, error)
+	CreateCustomer(ctx context.Context, customer entity.Customer) (entity.CustomerResponse, error)
+	UpdateCustomer(ctx context.Context, customer entity.Customer, customerId uuid.UUID) (entity.CustomerResponse, error)
+	DeleteCustomer(ctx context.Context, customerId uuid.UUID) error
 }
 
 type customerUseCase struct {
 	cr repository.CustomerRepository
+	cv validator.CustomerValidator
 }
 
-func NewCustomerUseCase(cr repository.CustomerRepository) CustomerUseCase {
-	return &customerUseCase{cr}
+func NewCustomerUseCase(cr repository.CustomerRepository, cv validator.CustomerValidator) CustomerUseCase {
+	return &customerUseCase{cr, cv}
 }
 
-func (cu *customerUseCase) GetAllCustomers() ([]entity.GetAllCustomerResponse, error) {
+func (cu *customerUseCase) GetAllCustomers(ctx context.Context) ([]entity.GetAllCustomerResponse, error) {
+	ctx, span := tracer.Start(ctx, "CustomerUseCase.GetAllCustomers")
+	defer span.End()
+
 	customers := []entity.Customer{}
-	if err := cu.cr.GetAllCustomers(context.Background(), &customers); err != nil {
+	if err := cu.cr.GetAllCustomers(ctx, &customers); err != nil {
 		return nil, err
 	}
 	resCustomers := []entity.GetAllCustomerResponse{}
@@ -37,9 +47,12 @@ func (cu *customerUseCase) GetAllCustomers() ([]entity.GetAllCustomerResponse, e
 	return resCustomers, nil
 }
 
-func (cu *customerUseCase) GetFilteredCustomers(query string) ([]entity.GetFilteredCustomerResponse, error) {
+func (cu *customerUseCase) GetFilteredCustomers(ctx context.Context, query string) ([]entity.GetFilteredCustomerResponse, error) {
+	ctx, span := tracer.Start(ctx, "CustomerUseCase.GetFilteredCustomers")
+	defer span.End()
+
 	customers := []entity.Customer{}
-	if err := cu.cr.GetFilteredCustomers(context.Background(), &customers, query); err != nil {
+	if err := cu.cr.GetFilteredCustomers(ctx, &customers, query); err != nil {
 		return nil, err
 	}
 
@@ -53,16 +66,91 @@ func (cu *customerUseCase) GetFilteredCustomers(query string) ([]entity.GetFilte
 		c.TotalInvoices = v.TotalInvoices
 		c.TotalPending = v.TotalPending
 		c.TotalPaid = v.TotalPaid
+		c.TotalCredited = v.TotalCredited
+		c.TotalRefunded = v.TotalRefunded
 		resCustomers = append(resCustomers, c)
 	}
 
 	return resCustomers, nil
 }
 
-func (cu *customerUseCase) GetCustomerCount() (int, error) {
-	count, err := cu.cr.GetCustomerCount(context.Background())
+func (cu *customerUseCase) GetCustomerCount(ctx context.Context) (int, error) {
+	ctx, span := tracer.Start(ctx, "CustomerUseCase.GetCustomerCount")
+	defer span.End()
+
+	count, err := cu.cr.GetCustomerCount(ctx)
 	if err != nil {
 		return 0, err
 	}
 	return count, nil
 }
+
+func (cu *customerUseCase) GetCustomerById(ctx context.Context, customerId uuid.UUID) (entity.CustomerResponse, error) {
+	ctx, span := tracer.Start(ctx, "CustomerUseCase.GetCustomerById")
+	defer span.End()
+
+	customer := entity.Customer{}
+	if err := cu.cr.GetCustomerById(ctx, &customer, customerId); err != nil {
+		return entity.CustomerResponse{}, err
+	}
+	return toCustomerResponse(customer), nil
+}
+
+func (cu *customerUseCase) CreateCustomer(ctx context.Context, customer entity.Customer) (entity.CustomerResponse, error) {
+	ctx, span := tracer.Start(ctx, "CustomerUseCase.CreateCustomer")
+	defer span.End()
+
+	if err := cu.cv.CustomerValidate(customer); err != nil {
+		return entity.CustomerResponse{}, err
+	}
+	newCustomer := entity.Customer{Name: customer.Name, Email: customer.Email, ImageUrl: customer.ImageUrl, PaymentTerms: customer.PaymentTerms}
+	if newCustomer.PaymentTerms == "" {
+		newCustomer.PaymentTerms = entity.DefaultPaymentTerms
+	}
+	if err := cu.cr.CreateCustomer(ctx, &newCustomer); err != nil {
+		return entity.CustomerResponse{}, err
+	}
+	return toCustomerResponse(newCustomer), nil
+}
+
+func (cu *customerUseCase) UpdateCustomer(ctx context.Context, customer entity.Customer, customerId uuid.UUID) (entity.CustomerResponse, error) {
+	ctx, span := tracer.Start(ctx, "CustomerUseCase.UpdateCustomer")
+	defer span.End()
+
+	if err := cu.cv.CustomerValidate(customer); err != nil {
+		return entity.CustomerResponse{}, err
+	}
+	// 支払条件が省略された場合は現在の値を引き継ぐ
+	if customer.PaymentTerms == "" {
+		current := entity.Customer{}
+		if err := cu.cr.GetCustomerById(ctx, &current, customerId); err != nil {
+			return entity.CustomerResponse{}, err
+		}
+		customer.PaymentTerms = current.PaymentTerms
+	}
+	if err := cu.cr.UpdateCustomer(ctx, &customer, customerId); err != nil {
+		return entity.CustomerResponse{}, err
+	}
+	customer.ID = customerId
+	return toCustomerResponse(customer), nil
+}
+
+func (cu *customerUseCase) DeleteCustomer(ctx context.Context, customerId uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "CustomerUseCase.DeleteCustomer")
+	defer span.End()
+
+	if err := cu.cr.DeleteCustomer(ctx, customerId); err != nil {
+		return err
+	}
+	return nil
+}
+
+func toCustomerResponse(customer entity.Customer) entity.CustomerResponse {
+	return entity.CustomerResponse{
+		ID:           customer.ID,
+		Name:         customer.Name,
+		Email:        customer.Email,
+		ImageUrl:     customer.ImageUrl,
+		PaymentTerms: customer.PaymentTerms,
+	}
+}
@@ -1,126 +1,259 @@
 package usecase
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"math"
+	"next-learn-go/apperror"
 	"next-learn-go/entity"
+	"next-learn-go/infrastructure/pdf"
 	"next-learn-go/repository"
 	"next-learn-go/validator"
+	"slices"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 type InvoiceUseCase interface {
-	GetLatestInvoices(offset, limit int) ([]entity.GetLatestInvoicesResponse, error)
-	GetFilteredInvoices(query string, offset, limit int) ([]entity.GetFilteredInvoicesResponse, error)
-	GetInvoiceCount() (int, error)
-	GetInvoiceStatusCount() (int, int, error)
-	GetInvoicesPages(query string, offset, limit int) (int, error)
-	GetInvoiceById(invoiceId uuid.UUID) (entity.GetInvoiceByIdResponse, error)
-	CreateInvoice(invoice entity.Invoice) (entity.InvoiceResponse, error)
-	UpdateInvoice(invoice entity.Invoice, invoiceId uuid.UUID) (entity.InvoiceResponse, error)
-	DeleteInvoice(invoiceId uuid.UUID) error
+	GetLatestInvoices(ctx context.Context, offset, limit int) ([]entity.GetLatestInvoicesResponse, error)
+	GetFilteredInvoices(ctx context.Context, filter entity.InvoiceFilter, offset, limit int) ([]entity.GetFilteredInvoicesResponse, error)
+	GetLatestInvoicesPage(ctx context.Context, cursor string, limit int) (entity.CursorPage[entity.GetLatestInvoicesResponse], error)
+	GetFilteredInvoicesPage(ctx context.Context, filter entity.InvoiceFilter, cursor string, limit int, withTotal bool) (entity.CursorPage[entity.GetFilteredInvoicesResponse], error)
+	GetInvoiceCount(ctx context.Context) (int, error)
+	GetInvoiceStatusCount(ctx context.Context) (map[string]int, error)
+	GetInvoicesPages(ctx context.Context, filter entity.InvoiceFilter, offset, limit int) (int, error)
+	GetInvoiceById(ctx context.Context, invoiceId uuid.UUID) (entity.GetInvoiceByIdResponse, error)
+	GetInvoiceByNumber(ctx context.Context, number string) (entity.GetInvoiceByIdResponse, error)
+	GetInvoicePdf(ctx context.Context, invoiceId uuid.UUID) ([]byte, error)
+	CreateInvoice(ctx context.Context, invoice entity.Invoice) (entity.InvoiceResponse, error)
+	UpdateInvoice(ctx context.Context, invoice entity.Invoice, invoiceId uuid.UUID) (entity.InvoiceResponse, error)
+	SendInvoice(ctx context.Context, invoiceId uuid.UUID) (entity.GetInvoiceByIdResponse, error)
+	VoidInvoice(ctx context.Context, invoiceId uuid.UUID) (entity.GetInvoiceByIdResponse, error)
+	DeleteInvoice(ctx context.Context, invoiceId uuid.UUID) error
+	MarkOverdueInvoices(ctx context.Context) (int, error)
 }
 
 type invoiceUseCase struct {
 	ir repository.InvoiceRepository
+	cr repository.CustomerRepository
 	iv validator.InvoiceValidator
+	pr pdf.InvoiceRenderer
 }
 
-func NewInvoiceUseCase(ir repository.InvoiceRepository, iv validator.InvoiceValidator) InvoiceUseCase {
-	return &invoiceUseCase{ir, iv}
+func NewInvoiceUseCase(ir repository.InvoiceRepository, cr repository.CustomerRepository, iv validator.InvoiceValidator, pr pdf.InvoiceRenderer) InvoiceUseCase {
+	return &invoiceUseCase{ir, cr, iv, pr}
 }
 
-func (iu *invoiceUseCase) GetLatestInvoices(offset, limit int) ([]entity.GetLatestInvoicesResponse, error) {
+func (iu *invoiceUseCase) GetLatestInvoices(ctx context.Context, offset, limit int) ([]entity.GetLatestInvoicesResponse, error) {
+	ctx, span := tracer.Start(ctx, "InvoiceUseCase.GetLatestInvoices")
+	defer span.End()
+
 	invoices := []entity.Invoice{}
-	if err := iu.ir.GetLatestInvoices(context.Background(), &invoices, offset, limit); err != nil {
+	if err := iu.ir.GetLatestInvoices(ctx, &invoices, offset, limit); err != nil {
 		return nil, err
 	}
-	resInvoices := []entity.GetLatestInvoicesResponse{}
-	for _, v := range invoices {
-		i := entity.GetLatestInvoicesResponse{}
-		i.ID = v.ID
-		i.Name = v.Customer.Name
-		i.ImageUrl = v.Customer.ImageUrl
-		i.Email = v.Customer.Email
-		i.Amount = v.Amount
-		resInvoices = append(resInvoices, i)
-	}
-	return resInvoices, nil
+	return toLatestInvoicesResponses(invoices), nil
 }
 
-func (iu *invoiceUseCase) GetFilteredInvoices(query string, offset, limit int) ([]entity.GetFilteredInvoicesResponse, error) {
+func (iu *invoiceUseCase) GetFilteredInvoices(ctx context.Context, filter entity.InvoiceFilter, offset, limit int) ([]entity.GetFilteredInvoicesResponse, error) {
+	ctx, span := tracer.Start(ctx, "InvoiceUseCase.GetFilteredInvoices")
+	defer span.End()
+
+	if err := iu.iv.InvoiceFilterValidate(filter); err != nil {
+		return nil, err
+	}
 	invoices := []entity.Invoice{}
-	if err := iu.ir.GetFilteredInvoices(context.Background(), &invoices, query, offset, limit); err != nil {
+	if err := iu.ir.GetFilteredInvoices(ctx, &invoices, filter, offset, limit); err != nil {
 		return nil, err
 	}
-	resInvoices := []entity.GetFilteredInvoicesResponse{}
-	for _, v := range invoices {
-		i := entity.GetFilteredInvoicesResponse{}
-		i.ID = v.ID
-		i.CustomerId = v.Customer.ID
-		i.Name = v.Customer.Name
-		i.Email = v.Customer.Email
-		i.ImageUrl = v.Customer.ImageUrl
-		i.Amount = v.Amount
-		i.Date = v.Date
-		i.Status = v.Status
-		resInvoices = append(resInvoices, i)
+	return toFilteredInvoicesResponses(invoices), nil
+}
+
+func (iu *invoiceUseCase) GetLatestInvoicesPage(ctx context.Context, cursor string, limit int) (entity.CursorPage[entity.GetLatestInvoicesResponse], error) {
+	ctx, span := tracer.Start(ctx, "InvoiceUseCase.GetLatestInvoicesPage")
+	defer span.End()
+
+	position, err := decodeCursor(cursor)
+	if err != nil {
+		return entity.CursorPage[entity.GetLatestInvoicesResponse]{}, err
+	}
+	if err := validatePageSize(limit); err != nil {
+		return entity.CursorPage[entity.GetLatestInvoicesResponse]{}, err
+	}
+	invoices := []entity.Invoice{}
+	hasMore, err := iu.ir.GetLatestInvoicesPage(ctx, &invoices, position, limit)
+	if err != nil {
+		return entity.CursorPage[entity.GetLatestInvoicesResponse]{}, err
+	}
+
+	page := entity.CursorPage[entity.GetLatestInvoicesResponse]{Data: toLatestInvoicesResponses(invoices)}
+	page.NextCursor, page.PrevCursor = pageCursors(invoices, position, hasMore)
+	return page, nil
+}
+
+func (iu *invoiceUseCase) GetFilteredInvoicesPage(ctx context.Context, filter entity.InvoiceFilter, cursor string, limit int, withTotal bool) (entity.CursorPage[entity.GetFilteredInvoicesResponse], error) {
+	ctx, span := tracer.Start(ctx, "InvoiceUseCase.GetFilteredInvoicesPage")
+	defer span.End()
+
+	if err := iu.iv.InvoiceFilterValidate(filter); err != nil {
+		return entity.CursorPage[entity.GetFilteredInvoicesResponse]{}, err
+	}
+	position, err := decodeCursor(cursor)
+	if err != nil {
+		return entity.CursorPage[entity.GetFilteredInvoicesResponse]{}, err
+	}
+	if err := validatePageSize(limit); err != nil {
+		return entity.CursorPage[entity.GetFilteredInvoicesResponse]{}, err
+	}
+	invoices := []entity.Invoice{}
+	hasMore, total, err := iu.ir.GetFilteredInvoicesPage(ctx, &invoices, filter, position, limit, withTotal)
+	if err != nil {
+		return entity.CursorPage[entity.GetFilteredInvoicesResponse]{}, err
+	}
+
+	page := entity.CursorPage[entity.GetFilteredInvoicesResponse]{Data: toFilteredInvoicesResponses(invoices)}
+	page.NextCursor, page.PrevCursor = pageCursors(invoices, position, hasMore)
+	if withTotal {
+		totalPages := (total + limit - 1) / limit
+		page.Total = &total
+		page.TotalPages = &totalPages
 	}
-	return resInvoices, nil
+	return page, nil
 }
 
-func (iu *invoiceUseCase) GetInvoiceCount() (int, error) {
-	count, err := iu.ir.GetInvoiceCount(context.Background())
+func (iu *invoiceUseCase) GetInvoiceCount(ctx context.Context) (int, error) {
+	ctx, span := tracer.Start(ctx, "InvoiceUseCase.GetInvoiceCount")
+	defer span.End()
+
+	count, err := iu.ir.GetInvoiceCount(ctx)
 	if err != nil {
 		return 0, err
 	}
 	return count, nil
 }
 
-func (iu *invoiceUseCase) GetInvoiceStatusCount() (int, int, error) {
-	pending, paid, err := iu.ir.GetInvoiceStatusCount(context.Background())
+// GetInvoiceStatusCount returns the number of invoices in every status,
+// including the statuses no invoice is in.
+func (iu *invoiceUseCase) GetInvoiceStatusCount(ctx context.Context) (map[string]int, error) {
+	ctx, span := tracer.Start(ctx, "InvoiceUseCase.GetInvoiceStatusCount")
+	defer span.End()
+
+	counts, err := iu.ir.CountInvoicesByStatus(ctx)
 	if err != nil {
-		return 0, 0, err
+		return nil, err
 	}
-	return pending, paid, nil
+	resCounts := make(map[string]int, len(entity.InvoiceStatuses))
+	for _, status := range entity.InvoiceStatuses {
+		resCounts[status] = counts[status]
+	}
+	return resCounts, nil
 }
 
-func (iu *invoiceUseCase) GetInvoicesPages(query string, offset, limit int) (int, error) {
-	count, err := iu.ir.GetInvoicesPages(context.Background(), query, offset, limit)
+func (iu *invoiceUseCase) GetInvoicesPages(ctx context.Context, filter entity.InvoiceFilter, offset, limit int) (int, error) {
+	ctx, span := tracer.Start(ctx, "InvoiceUseCase.GetInvoicesPages")
+	defer span.End()
+
+	if err := iu.iv.InvoiceFilterValidate(filter); err != nil {
+		return 0, err
+	}
+	count, err := iu.ir.GetInvoicesPages(ctx, filter, offset, limit)
 	if err != nil {
 		return 0, err
 	}
 	return count, nil
 }
 
-func (iu *invoiceUseCase) GetInvoiceById(invoiceId uuid.UUID) (entity.GetInvoiceByIdResponse, error) {
+func (iu *invoiceUseCase) GetInvoiceById(ctx context.Context, invoiceId uuid.UUID) (entity.GetInvoiceByIdResponse, error) {
+	ctx, span := tracer.Start(ctx, "InvoiceUseCase.GetInvoiceById")
+	defer span.End()
+
 	invoice := entity.Invoice{}
-	if err := iu.ir.GetInvoiceById(context.Background(), &invoice, invoiceId); err != nil {
+	if err := iu.ir.GetInvoiceById(ctx, &invoice, invoiceId); err != nil {
 		return entity.GetInvoiceByIdResponse{}, err
 	}
+	return toInvoiceByIdResponse(invoice), nil
+}
 
-	resInvoice := entity.GetInvoiceByIdResponse{}
-	resInvoice.ID = invoice.ID
-	resInvoice.CustomerId = invoice.Customer.ID
-	resInvoice.Amount = invoice.Amount
-	resInvoice.Status = invoice.Status
+func (iu *invoiceUseCase) GetInvoiceByNumber(ctx context.Context, number string) (entity.GetInvoiceByIdResponse, error) {
+	ctx, span := tracer.Start(ctx, "InvoiceUseCase.GetInvoiceByNumber")
+	defer span.End()
 
-	return resInvoice, nil
+	invoice := entity.Invoice{}
+	if err := iu.ir.GetInvoiceByNumber(ctx, &invoice, number); err != nil {
+		return entity.GetInvoiceByIdResponse{}, err
+	}
+	return toInvoiceByIdResponse(invoice), nil
 }
 
-func (iu *invoiceUseCase) CreateInvoice(invoice entity.Invoice) (entity.InvoiceResponse, error) {
+func (iu *invoiceUseCase) GetInvoicePdf(ctx context.Context, invoiceId uuid.UUID) ([]byte, error) {
+	ctx, span := tracer.Start(ctx, "InvoiceUseCase.GetInvoicePdf")
+	defer span.End()
+
+	invoice := entity.Invoice{}
+	if err := iu.ir.GetInvoiceById(ctx, &invoice, invoiceId); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := iu.pr.RenderInvoice(&buf, invoice); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (iu *invoiceUseCase) CreateInvoice(ctx context.Context, invoice entity.Invoice) (entity.InvoiceResponse, error) {
+	ctx, span := tracer.Start(ctx, "InvoiceUseCase.CreateInvoice")
+	defer span.End()
+
 	if err := iu.iv.InvoiceValidate(invoice); err != nil {
 		return entity.InvoiceResponse{}, err
 	}
-	if err := iu.ir.CreateInvoice(context.Background(), &invoice); err != nil {
+	if invoice.Status == "" {
+		invoice.Status = entity.InvoiceStatusDraft
+	}
+	if !slices.Contains(initialInvoiceStatuses, invoice.Status) {
+		return entity.InvoiceResponse{}, apperror.InvalidField("status", "new invoices must be draft, pending or paid")
+	}
+	customer := entity.Customer{}
+	if err := iu.cr.GetCustomerById(ctx, &customer, invoice.CustomerId); err != nil {
+		if apperror.Is(err, apperror.KindNotFound) {
+			return entity.InvoiceResponse{}, apperror.InvalidField("customer_id", "customer does not exist")
+		}
+		return entity.InvoiceResponse{}, err
+	}
+	invoice.Customer = customer
+	if invoice.Date.IsZero() {
+		invoice.Date = time.Now()
+	}
+	if err := applyPaymentTerms(&invoice, customer.PaymentTerms); err != nil {
+		return entity.InvoiceResponse{}, err
+	}
+	calculateInvoiceTotals(&invoice)
+	invoice.Payments = nil
+	// 支払済みで登録する請求書は、発行日に全額を受け取ったものとして入金を記録する
+	if invoice.Status == entity.InvoiceStatusPaid && invoice.Amount > 0 {
+		invoice.Payments = []entity.Payment{{
+			Amount:     invoice.Amount,
+			Method:     entity.PaymentMethodOther,
+			ReceivedAt: invoice.Date,
+		}}
+	}
+	if err := iu.ir.CreateInvoice(ctx, &invoice); err != nil {
 		return entity.InvoiceResponse{}, err
 	}
 
 	resInvoice := entity.InvoiceResponse{}
 	resInvoice.ID = invoice.ID
+	resInvoice.Number = invoice.Number
+	resInvoice.Subtotal = invoice.Subtotal
+	resInvoice.Tax = invoice.Tax
 	resInvoice.Amount = invoice.Amount
 	resInvoice.Date = invoice.Date
+	resInvoice.PaymentTerms = invoice.PaymentTerms
+	resInvoice.DueDate = invoice.DueDate
 	resInvoice.Status = invoice.Status
+	resInvoice.Items = toInvoiceItemResponses(invoice.Items)
 	resInvoice.Customer.Name = invoice.Customer.Name
 	resInvoice.Customer.Email = invoice.Customer.Email
 	resInvoice.Customer.ImageUrl = invoice.Customer.ImageUrl
@@ -128,25 +261,209 @@ func (iu *invoiceUseCase) CreateInvoice(invoice entity.Invoice) (entity.InvoiceR
 	return resInvoice, nil
 }
 
-func (iu *invoiceUseCase) UpdateInvoice(invoice entity.Invoice, invoiceId uuid.UUID) (entity.InvoiceResponse, error) {
+func (iu *invoiceUseCase) UpdateInvoice(ctx context.Context, invoice entity.Invoice, invoiceId uuid.UUID) (entity.InvoiceResponse, error) {
+	ctx, span := tracer.Start(ctx, "InvoiceUseCase.UpdateInvoice")
+	defer span.End()
+
 	if err := iu.iv.InvoiceValidate(invoice); err != nil {
 		return entity.InvoiceResponse{}, err
 	}
-	if err := iu.ir.UpdateInvoice(context.Background(), &invoice, invoiceId); err != nil {
+	current := entity.Invoice{}
+	if err := iu.ir.GetInvoiceById(ctx, &current, invoiceId); err != nil {
+		return entity.InvoiceResponse{}, err
+	}
+	// 確定した請求書は書き換えず、取消して作り直してもらう
+	if current.Status == entity.InvoiceStatusPaid || current.Status == entity.InvoiceStatusVoid {
+		return entity.InvoiceResponse{}, apperror.Conflict(fmt.Sprintf("%s invoices cannot be edited", current.Status))
+	}
+	if invoice.Status == "" {
+		invoice.Status = current.Status
+	}
+	// 入金や貸方票の後に金額を変えると、それらの記録と合わなくなる
+	if len(current.Payments) > 0 || len(current.CreditNotes) > 0 {
+		return entity.InvoiceResponse{}, apperror.Conflict("invoices with payments or credit notes cannot be edited; issue a credit note instead")
+	}
+	if invoice.Status != current.Status {
+		if slices.Contains(paymentInvoiceStatuses, invoice.Status) {
+			return entity.InvoiceResponse{}, apperror.InvalidField("status", "is set by recording payments")
+		}
+		if err := checkInvoiceTransition(current.Status, invoice.Status); err != nil {
+			return entity.InvoiceResponse{}, err
+		}
+	}
+	// 発行日は変更できないため、期日は元の発行日から計算し直す
+	invoice.Date = current.Date
+	if invoice.PaymentTerms == "" && invoice.DueDate.IsZero() {
+		invoice.PaymentTerms = current.PaymentTerms
+		invoice.DueDate = current.DueDate
+	} else if err := applyPaymentTerms(&invoice, current.PaymentTerms); err != nil {
+		return entity.InvoiceResponse{}, err
+	}
+	calculateInvoiceTotals(&invoice)
+	if err := iu.ir.UpdateInvoice(ctx, &invoice, invoiceId, current.Status); err != nil {
 		return entity.InvoiceResponse{}, err
 	}
 
 	resInvoice := entity.InvoiceResponse{}
 	resInvoice.ID = invoice.ID
+	resInvoice.Number = current.Number
+	resInvoice.Subtotal = invoice.Subtotal
+	resInvoice.Tax = invoice.Tax
 	resInvoice.Amount = invoice.Amount
+	resInvoice.Date = invoice.Date
+	resInvoice.PaymentTerms = invoice.PaymentTerms
+	resInvoice.DueDate = invoice.DueDate
 	resInvoice.Status = invoice.Status
+	resInvoice.Items = toInvoiceItemResponses(invoice.Items)
 
 	return resInvoice, nil
 }
 
-func (iu *invoiceUseCase) DeleteInvoice(invoiceId uuid.UUID) error {
-	if err := iu.ir.DeleteInvoice(context.Background(), invoiceId); err != nil {
+func (iu *invoiceUseCase) DeleteInvoice(ctx context.Context, invoiceId uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "InvoiceUseCase.DeleteInvoice")
+	defer span.End()
+
+	if err := iu.ir.DeleteInvoice(ctx, invoiceId); err != nil {
 		return err
 	}
 	return nil
 }
+
+// MarkOverdueInvoices moves the outstanding invoices past their due date to
+// overdue and returns how many were moved.
+func (iu *invoiceUseCase) MarkOverdueInvoices(ctx context.Context) (int, error) {
+	ctx, span := tracer.Start(ctx, "InvoiceUseCase.MarkOverdueInvoices")
+	defer span.End()
+
+	return iu.ir.MarkInvoicesOverdue(ctx, statusesLeadingTo(entity.InvoiceStatusOverdue))
+}
+
+// applyPaymentTerms sets the due date from the invoice's payment terms, or
+// from defaultTerms when it has none. A due date given without terms is kept
+// as a custom due date.
+func applyPaymentTerms(invoice *entity.Invoice, defaultTerms string) error {
+	if invoice.PaymentTerms == "" {
+		if invoice.DueDate.IsZero() {
+			invoice.PaymentTerms = defaultTerms
+		} else {
+			invoice.PaymentTerms = entity.PaymentTermsCustom
+		}
+	}
+	if days, ok := entity.PaymentTermDays[invoice.PaymentTerms]; ok {
+		invoice.DueDate = invoice.Date.AddDate(0, 0, days)
+	}
+	if startOfDay(invoice.DueDate).Before(startOfDay(invoice.Date)) {
+		return apperror.InvalidField("due_date", "must not be before the invoice date")
+	}
+	return nil
+}
+
+// daysOverdue returns how many days an outstanding invoice is past its due
+// date as of today.
+func daysOverdue(invoice entity.Invoice, today time.Time) int {
+	if !slices.Contains(entity.OutstandingInvoiceStatuses, invoice.Status) || invoice.DueDate.IsZero() {
+		return 0
+	}
+	days := int(startOfDay(today).Sub(startOfDay(invoice.DueDate)).Hours() / 24)
+	return max(days, 0)
+}
+
+// startOfDay drops the time of day so that dates read from DATE columns and
+// the local clock can be compared.
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// calculateInvoiceTotals derives every line and invoice total from quantity,
+// unit price and tax rate, ignoring any amounts supplied by the client.
+func calculateInvoiceTotals(invoice *entity.Invoice) {
+	invoice.Subtotal = 0
+	invoice.Tax = 0
+	for i := range invoice.Items {
+		item := &invoice.Items[i]
+		item.Subtotal = item.Quantity * item.UnitPrice
+		item.Tax = int(math.Round(float64(item.Subtotal) * item.TaxRate / 100))
+		item.Total = item.Subtotal + item.Tax
+		invoice.Subtotal += item.Subtotal
+		invoice.Tax += item.Tax
+	}
+	invoice.Amount = invoice.Subtotal + invoice.Tax
+}
+
+func toInvoiceByIdResponse(invoice entity.Invoice) entity.GetInvoiceByIdResponse {
+	resInvoice := entity.GetInvoiceByIdResponse{}
+	resInvoice.ID = invoice.ID
+	resInvoice.Number = invoice.Number
+	resInvoice.CustomerId = invoice.Customer.ID
+	resInvoice.Subtotal = invoice.Subtotal
+	resInvoice.Tax = invoice.Tax
+	resInvoice.Amount = invoice.Amount
+	resInvoice.Status = invoice.Status
+	resInvoice.PaymentTerms = invoice.PaymentTerms
+	resInvoice.DueDate = invoice.DueDate
+	balance := entity.NewInvoiceBalance(invoice)
+	resInvoice.AmountPaid = balance.NetPaid()
+	resInvoice.AmountCredited = balance.Credited
+	resInvoice.BalanceDue = balance.Due(invoice.Amount)
+	resInvoice.Items = toInvoiceItemResponses(invoice.Items)
+	resInvoice.StatusHistory = toInvoiceStatusChangeResponses(invoice.StatusHistory)
+	resInvoice.CreditNotes = toCreditNoteResponses(invoice.CreditNotes)
+	if invoice.RecurringInvoiceId.Valid {
+		resInvoice.RecurringInvoiceId = &invoice.RecurringInvoiceId.UUID
+	}
+	return resInvoice
+}
+
+func toLatestInvoicesResponses(invoices []entity.Invoice) []entity.GetLatestInvoicesResponse {
+	resInvoices := []entity.GetLatestInvoicesResponse{}
+	for _, v := range invoices {
+		i := entity.GetLatestInvoicesResponse{}
+		i.ID = v.ID
+		i.Number = v.Number
+		i.Name = v.Customer.Name
+		i.ImageUrl = v.Customer.ImageUrl
+		i.Email = v.Customer.Email
+		i.Amount = v.Amount
+		resInvoices = append(resInvoices, i)
+	}
+	return resInvoices
+}
+
+func toFilteredInvoicesResponses(invoices []entity.Invoice) []entity.GetFilteredInvoicesResponse {
+	today := time.Now()
+	resInvoices := []entity.GetFilteredInvoicesResponse{}
+	for _, v := range invoices {
+		i := entity.GetFilteredInvoicesResponse{}
+		i.ID = v.ID
+		i.Number = v.Number
+		i.CustomerId = v.Customer.ID
+		i.Name = v.Customer.Name
+		i.Email = v.Customer.Email
+		i.ImageUrl = v.Customer.ImageUrl
+		i.Amount = v.Amount
+		i.Date = v.Date
+		i.DueDate = v.DueDate
+		i.DaysOverdue = daysOverdue(v, today)
+		i.Status = v.Status
+		resInvoices = append(resInvoices, i)
+	}
+	return resInvoices
+}
+
+func toInvoiceItemResponses(items []entity.InvoiceItem) []entity.InvoiceItemResponse {
+	resItems := []entity.InvoiceItemResponse{}
+	for _, v := range items {
+		item := entity.InvoiceItemResponse{}
+		item.ID = v.ID
+		item.Description = v.Description
+		item.Quantity = v.Quantity
+		item.UnitPrice = v.UnitPrice
+		item.TaxRate = v.TaxRate
+		item.Subtotal = v.Subtotal
+		item.Tax = v.Tax
+		item.Total = v.Total
+		resItems = append(resItems, item)
+	}
+	return resItems
+}
@@ -0,0 +1,145 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"next-learn-go/apperror"
+	"next-learn-go/entity"
+	"slices"
+
+	"github.com/google/uuid"
+)
+
+// invoiceTransitions lists the statuses each status may move to. Paid and void
+// invoices are final, except for the edges in ledgerInvoiceTransitions.
+var invoiceTransitions = map[string][]string{
+	entity.InvoiceStatusDraft: {
+		entity.InvoiceStatusSent,
+		entity.InvoiceStatusPending,
+		entity.InvoiceStatusVoid,
+	},
+	entity.InvoiceStatusSent: {
+		entity.InvoiceStatusPending,
+		entity.InvoiceStatusPartiallyPaid,
+		entity.InvoiceStatusPaid,
+		entity.InvoiceStatusOverdue,
+		entity.InvoiceStatusVoid,
+	},
+	entity.InvoiceStatusPending: {
+		entity.InvoiceStatusPartiallyPaid,
+		entity.InvoiceStatusPaid,
+		entity.InvoiceStatusOverdue,
+		entity.InvoiceStatusVoid,
+	},
+	entity.InvoiceStatusPartiallyPaid: {
+		entity.InvoiceStatusPaid,
+		entity.InvoiceStatusOverdue,
+		entity.InvoiceStatusVoid,
+	},
+	entity.InvoiceStatusOverdue: {
+		entity.InvoiceStatusPartiallyPaid,
+		entity.InvoiceStatusPaid,
+		entity.InvoiceStatusVoid,
+	},
+}
+
+// ledgerInvoiceTransitions lists the extra moves made when payments are
+// removed or refunded, which reopen a settled invoice. Only the payment ledger
+// takes them; they cannot be requested through the status endpoints.
+var ledgerInvoiceTransitions = map[string][]string{
+	entity.InvoiceStatusPaid: {
+		entity.InvoiceStatusPending,
+		entity.InvoiceStatusPartiallyPaid,
+		entity.InvoiceStatusOverdue,
+	},
+	entity.InvoiceStatusPartiallyPaid: {
+		entity.InvoiceStatusPending,
+	},
+}
+
+// 請求書はこれらの状態でのみ新規作成できる。既存の呼び出し元のため pending と paid も認める
+var initialInvoiceStatuses = []string{
+	entity.InvoiceStatusDraft,
+	entity.InvoiceStatusPending,
+	entity.InvoiceStatusPaid,
+}
+
+// 入金状況で決まる状態は、入金の記録からのみ遷移させる
+var paymentInvoiceStatuses = []string{
+	entity.InvoiceStatusPartiallyPaid,
+	entity.InvoiceStatusPaid,
+}
+
+func checkInvoiceTransition(from, to string) error {
+	if from == to {
+		return apperror.Conflict(fmt.Sprintf("invoice is already %s", to))
+	}
+	if !slices.Contains(invoiceTransitions[from], to) {
+		return apperror.Conflict(fmt.Sprintf("invoice cannot change from %s to %s", from, to))
+	}
+	return nil
+}
+
+// checkLedgerTransition is checkInvoiceTransition for changes made by the
+// payment ledger. Staying in the same status is not a change.
+func checkLedgerTransition(from, to string) error {
+	if from == to || slices.Contains(ledgerInvoiceTransitions[from], to) {
+		return nil
+	}
+	return checkInvoiceTransition(from, to)
+}
+
+// statusesLeadingTo returns the statuses that may move to status.
+func statusesLeadingTo(status string) []string {
+	statuses := []string{}
+	for _, from := range entity.InvoiceStatuses {
+		if slices.Contains(invoiceTransitions[from], status) {
+			statuses = append(statuses, from)
+		}
+	}
+	return statuses
+}
+
+func (iu *invoiceUseCase) SendInvoice(ctx context.Context, invoiceId uuid.UUID) (entity.GetInvoiceByIdResponse, error) {
+	ctx, span := tracer.Start(ctx, "InvoiceUseCase.SendInvoice")
+	defer span.End()
+
+	return iu.transitionInvoice(ctx, invoiceId, entity.InvoiceStatusSent)
+}
+
+func (iu *invoiceUseCase) VoidInvoice(ctx context.Context, invoiceId uuid.UUID) (entity.GetInvoiceByIdResponse, error) {
+	ctx, span := tracer.Start(ctx, "InvoiceUseCase.VoidInvoice")
+	defer span.End()
+
+	return iu.transitionInvoice(ctx, invoiceId, entity.InvoiceStatusVoid)
+}
+
+func (iu *invoiceUseCase) transitionInvoice(ctx context.Context, invoiceId uuid.UUID, to string) (entity.GetInvoiceByIdResponse, error) {
+	invoice := entity.Invoice{}
+	if err := iu.ir.GetInvoiceById(ctx, &invoice, invoiceId); err != nil {
+		return entity.GetInvoiceByIdResponse{}, err
+	}
+	if err := checkInvoiceTransition(invoice.Status, to); err != nil {
+		return entity.GetInvoiceByIdResponse{}, err
+	}
+	// 入金のある請求書を取り消すと受け取ったお金の行き場がなくなる
+	if to == entity.InvoiceStatusVoid && entity.NewInvoiceBalance(invoice).NetPaid() > 0 {
+		return entity.GetInvoiceByIdResponse{}, apperror.Conflict("invoices with payments cannot be voided")
+	}
+	if err := iu.ir.UpdateInvoiceStatus(ctx, invoiceId, invoice.Status, to); err != nil {
+		return entity.GetInvoiceByIdResponse{}, err
+	}
+	return iu.GetInvoiceById(ctx, invoiceId)
+}
+
+func toInvoiceStatusChangeResponses(changes []entity.InvoiceStatusChange) []entity.InvoiceStatusChangeResponse {
+	resChanges := []entity.InvoiceStatusChangeResponse{}
+	for _, v := range changes {
+		change := entity.InvoiceStatusChangeResponse{}
+		change.FromStatus = v.FromStatus.String
+		change.ToStatus = v.ToStatus
+		change.ChangedAt = v.ChangedAt
+		resChanges = append(resChanges, change)
+	}
+	return resChanges
+}
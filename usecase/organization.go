@@ -0,0 +1,115 @@
+package usecase
+
+import (
+	"context"
+	"next-learn-go/apperror"
+	"next-learn-go/entity"
+	"next-learn-go/repository"
+	"next-learn-go/validator"
+
+	"github.com/google/uuid"
+)
+
+type OrganizationUseCase interface {
+	GetMyOrganizations(ctx context.Context, userId uuid.UUID) ([]entity.OrganizationResponse, error)
+	GetMembers(ctx context.Context, organizationId uuid.UUID) ([]entity.OrganizationMemberResponse, error)
+	AddMember(ctx context.Context, organizationId uuid.UUID, req entity.AddOrganizationMemberRequest) (entity.OrganizationMemberResponse, error)
+	UpdateMemberRole(ctx context.Context, organizationId, actorId, userId uuid.UUID, req entity.UpdateUserRoleRequest) (entity.OrganizationMemberResponse, error)
+}
+
+type organizationUseCase struct {
+	or repository.OrganizationRepository
+	ur repository.UserRepository
+	ov validator.OrganizationValidator
+}
+
+func NewOrganizationUseCase(or repository.OrganizationRepository, ur repository.UserRepository, ov validator.OrganizationValidator) OrganizationUseCase {
+	return &organizationUseCase{or, ur, ov}
+}
+
+func (ou *organizationUseCase) GetMyOrganizations(ctx context.Context, userId uuid.UUID) ([]entity.OrganizationResponse, error) {
+	ctx, span := tracer.Start(ctx, "OrganizationUseCase.GetMyOrganizations")
+	defer span.End()
+
+	members := []entity.OrganizationMember{}
+	if err := ou.or.GetMembershipsByUser(ctx, &members, userId); err != nil {
+		return nil, err
+	}
+	resOrganizations := []entity.OrganizationResponse{}
+	for _, v := range members {
+		o := entity.OrganizationResponse{}
+		o.ID = v.OrganizationId
+		o.Name = v.Organization.Name
+		o.Role = v.Role
+		resOrganizations = append(resOrganizations, o)
+	}
+	return resOrganizations, nil
+}
+
+func (ou *organizationUseCase) GetMembers(ctx context.Context, organizationId uuid.UUID) ([]entity.OrganizationMemberResponse, error) {
+	ctx, span := tracer.Start(ctx, "OrganizationUseCase.GetMembers")
+	defer span.End()
+
+	members := []entity.OrganizationMember{}
+	if err := ou.or.GetMembersByOrganization(ctx, &members, organizationId); err != nil {
+		return nil, err
+	}
+	resMembers := []entity.OrganizationMemberResponse{}
+	for _, v := range members {
+		resMembers = append(resMembers, toOrganizationMemberResponse(v, *v.User))
+	}
+	return resMembers, nil
+}
+
+func (ou *organizationUseCase) AddMember(ctx context.Context, organizationId uuid.UUID, req entity.AddOrganizationMemberRequest) (entity.OrganizationMemberResponse, error) {
+	ctx, span := tracer.Start(ctx, "OrganizationUseCase.AddMember")
+	defer span.End()
+
+	if err := ou.ov.OrganizationMemberValidate(req); err != nil {
+		return entity.OrganizationMemberResponse{}, err
+	}
+	// 招待できるのは登録済みのユーザーのみ
+	user := entity.User{}
+	if err := ou.ur.GetUserByEmail(ctx, &user, req.Email); err != nil {
+		return entity.OrganizationMemberResponse{}, err
+	}
+	member := entity.OrganizationMember{OrganizationId: organizationId, UserId: user.ID, Role: req.Role}
+	if err := ou.or.AddMember(ctx, &member); err != nil {
+		return entity.OrganizationMemberResponse{}, err
+	}
+	return toOrganizationMemberResponse(member, user), nil
+}
+
+func (ou *organizationUseCase) UpdateMemberRole(ctx context.Context, organizationId, actorId, userId uuid.UUID, req entity.UpdateUserRoleRequest) (entity.OrganizationMemberResponse, error) {
+	ctx, span := tracer.Start(ctx, "OrganizationUseCase.UpdateMemberRole")
+	defer span.End()
+
+	if err := ou.ov.OrganizationRoleValidate(req); err != nil {
+		return entity.OrganizationMemberResponse{}, err
+	}
+	// 管理者が自分の権限を外して組織を管理できなくなるのを防ぐ
+	if actorId == userId {
+		return entity.OrganizationMemberResponse{}, apperror.Forbidden("you cannot change your own role")
+	}
+	if err := ou.or.UpdateMemberRole(ctx, organizationId, userId, req.Role); err != nil {
+		return entity.OrganizationMemberResponse{}, err
+	}
+	member := entity.OrganizationMember{}
+	if err := ou.or.GetMembership(ctx, &member, organizationId, userId); err != nil {
+		return entity.OrganizationMemberResponse{}, err
+	}
+	user := entity.User{}
+	if err := ou.ur.GetUserById(ctx, &user, userId); err != nil {
+		return entity.OrganizationMemberResponse{}, err
+	}
+	return toOrganizationMemberResponse(member, user), nil
+}
+
+func toOrganizationMemberResponse(member entity.OrganizationMember, user entity.User) entity.OrganizationMemberResponse {
+	return entity.OrganizationMemberResponse{
+		UserId: member.UserId,
+		Name:   user.Name,
+		Email:  user.Email,
+		Role:   member.Role,
+	}
+}
@@ -0,0 +1,73 @@
+package usecase
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"next-learn-go/apperror"
+	"next-learn-go/entity"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// 1 ページで返す件数の上限
+const maxPageSize = 100
+
+type cursorToken struct {
+	Date     time.Time `json:"d"`
+	ID       uuid.UUID `json:"i"`
+	Backward bool      `json:"b,omitempty"`
+}
+
+// encodeCursor turns a position into an opaque token. Clients must not rely
+// on its content, which may change.
+func encodeCursor(cursor entity.Cursor) string {
+	b, _ := json.Marshal(cursorToken{cursor.Date, cursor.ID, cursor.Backward})
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// decodeCursor parses a token from encodeCursor. The empty token is the first
+// page.
+func decodeCursor(token string) (entity.Cursor, error) {
+	if token == "" {
+		return entity.Cursor{}, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return entity.Cursor{}, apperror.InvalidField("cursor", "invalid cursor")
+	}
+	t := cursorToken{}
+	if err := json.Unmarshal(b, &t); err != nil || t.ID == uuid.Nil {
+		return entity.Cursor{}, apperror.InvalidField("cursor", "invalid cursor")
+	}
+	return entity.Cursor{Date: t.Date, ID: t.ID, Backward: t.Backward}, nil
+}
+
+func validatePageSize(limit int) error {
+	if limit < 1 || limit > maxPageSize {
+		return apperror.InvalidField("limit", "limit must be between 1 and 100")
+	}
+	return nil
+}
+
+// pageCursors returns the tokens of the pages around invoices, which were
+// read from cursor and are in (date DESC, id DESC) order. hasMore reports
+// whether rows remain in the direction the page was read.
+func pageCursors(invoices []entity.Invoice, cursor entity.Cursor, hasMore bool) (next, prev string) {
+	if len(invoices) == 0 {
+		return "", ""
+	}
+	first, last := invoices[0], invoices[len(invoices)-1]
+	// 前向きに読んだページの前には cursor が指す行がある。後ろ向きの場合も同様
+	hasNext, hasPrev := hasMore, !cursor.IsZero()
+	if cursor.Backward {
+		hasNext, hasPrev = true, hasMore
+	}
+	if hasNext {
+		next = encodeCursor(entity.Cursor{Date: last.Date, ID: last.ID})
+	}
+	if hasPrev {
+		prev = encodeCursor(entity.Cursor{Date: first.Date, ID: first.ID, Backward: true})
+	}
+	return next, prev
+}
@@ -0,0 +1,214 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"next-learn-go/apperror"
+	"next-learn-go/entity"
+	"next-learn-go/repository"
+	"next-learn-go/validator"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PaymentUseCase interface {
+	GetPayments(ctx context.Context, invoiceId uuid.UUID) (entity.InvoicePaymentsResponse, error)
+	CreatePayment(ctx context.Context, payment entity.Payment, invoiceId uuid.UUID) (entity.PaymentResponse, error)
+	DeletePayment(ctx context.Context, invoiceId, paymentId uuid.UUID) error
+	PayInvoice(ctx context.Context, invoiceId uuid.UUID) (entity.InvoicePaymentsResponse, error)
+	CreateRefund(ctx context.Context, refund entity.Refund, invoiceId, paymentId uuid.UUID) (entity.RefundResponse, error)
+}
+
+type paymentUseCase struct {
+	pr repository.PaymentRepository
+	ir repository.InvoiceRepository
+	pv validator.PaymentValidator
+}
+
+func NewPaymentUseCase(pr repository.PaymentRepository, ir repository.InvoiceRepository, pv validator.PaymentValidator) PaymentUseCase {
+	return &paymentUseCase{pr, ir, pv}
+}
+
+func (pu *paymentUseCase) GetPayments(ctx context.Context, invoiceId uuid.UUID) (entity.InvoicePaymentsResponse, error) {
+	ctx, span := tracer.Start(ctx, "PaymentUseCase.GetPayments")
+	defer span.End()
+
+	invoice := entity.Invoice{}
+	if err := pu.ir.GetInvoiceById(ctx, &invoice, invoiceId); err != nil {
+		return entity.InvoicePaymentsResponse{}, err
+	}
+	return toInvoicePaymentsResponse(invoice), nil
+}
+
+func (pu *paymentUseCase) CreatePayment(ctx context.Context, payment entity.Payment, invoiceId uuid.UUID) (entity.PaymentResponse, error) {
+	ctx, span := tracer.Start(ctx, "PaymentUseCase.CreatePayment")
+	defer span.End()
+
+	if err := pu.pv.PaymentValidate(payment); err != nil {
+		return entity.PaymentResponse{}, err
+	}
+	newPayment := entity.Payment{
+		InvoiceId:  invoiceId,
+		Amount:     payment.Amount,
+		Method:     payment.Method,
+		Reference:  payment.Reference,
+		ReceivedAt: payment.ReceivedAt,
+	}
+	if newPayment.ReceivedAt.IsZero() {
+		newPayment.ReceivedAt = time.Now()
+	}
+	if err := pu.pr.CreatePayment(ctx, &newPayment, settlePayment); err != nil {
+		return entity.PaymentResponse{}, err
+	}
+	return toPaymentResponse(newPayment), nil
+}
+
+func (pu *paymentUseCase) DeletePayment(ctx context.Context, invoiceId, paymentId uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "PaymentUseCase.DeletePayment")
+	defer span.End()
+
+	if err := pu.pr.DeletePayment(ctx, invoiceId, paymentId, settleInvoice); err != nil {
+		return err
+	}
+	return nil
+}
+
+// PayInvoice records a payment of the whole balance due, for invoices that
+// are settled outside the payment ledger.
+func (pu *paymentUseCase) PayInvoice(ctx context.Context, invoiceId uuid.UUID) (entity.InvoicePaymentsResponse, error) {
+	ctx, span := tracer.Start(ctx, "PaymentUseCase.PayInvoice")
+	defer span.End()
+
+	invoice := entity.Invoice{}
+	if err := pu.ir.GetInvoiceById(ctx, &invoice, invoiceId); err != nil {
+		return entity.InvoicePaymentsResponse{}, err
+	}
+	due := entity.NewInvoiceBalance(invoice).Due(invoice.Amount)
+	if due <= 0 {
+		return entity.InvoicePaymentsResponse{}, apperror.Conflict("invoice has no balance due")
+	}
+	payment := entity.Payment{
+		InvoiceId:  invoiceId,
+		Amount:     due,
+		Method:     entity.PaymentMethodOther,
+		ReceivedAt: time.Now(),
+	}
+	// 読み込んだ後に入金があれば残高を超えるため、settlePayment が拒否する
+	if err := pu.pr.CreatePayment(ctx, &payment, settlePayment); err != nil {
+		return entity.InvoicePaymentsResponse{}, err
+	}
+	return pu.GetPayments(ctx, invoiceId)
+}
+
+func (pu *paymentUseCase) CreateRefund(ctx context.Context, refund entity.Refund, invoiceId, paymentId uuid.UUID) (entity.RefundResponse, error) {
+	ctx, span := tracer.Start(ctx, "PaymentUseCase.CreateRefund")
+	defer span.End()
+
+	if err := pu.pv.RefundValidate(refund); err != nil {
+		return entity.RefundResponse{}, err
+	}
+	newRefund := entity.Refund{
+		PaymentId:  paymentId,
+		Amount:     refund.Amount,
+		Reason:     refund.Reason,
+		RefundedAt: refund.RefundedAt,
+	}
+	if newRefund.RefundedAt.IsZero() {
+		newRefund.RefundedAt = time.Now()
+	}
+	if err := pu.pr.CreateRefund(ctx, &newRefund, invoiceId, settleInvoice); err != nil {
+		return entity.RefundResponse{}, err
+	}
+	return toRefundResponse(newRefund), nil
+}
+
+// settlePayment settles an invoice after a payment has been added, which must
+// not take the amount paid beyond what is owed.
+func settlePayment(invoice entity.Invoice, balance entity.InvoiceBalance) (string, error) {
+	status, err := settleInvoice(invoice, balance)
+	if err != nil {
+		return "", err
+	}
+	if due := balance.Due(invoice.Amount); due < 0 {
+		return "", apperror.InvalidField("amount", fmt.Sprintf("payments would exceed the balance due by %d", -due))
+	}
+	return status, nil
+}
+
+// settleInvoice moves an invoice to paid once its payments and credit notes
+// cover the amount, to partially_paid while they cover part of it and back to
+// an unpaid status when payments are removed or refunded. Invoices past their
+// due date stay overdue until they are settled in full.
+func settleInvoice(invoice entity.Invoice, balance entity.InvoiceBalance) (string, error) {
+	switch invoice.Status {
+	case entity.InvoiceStatusDraft:
+		return "", apperror.Conflict("draft invoices cannot take payments; send the invoice first")
+	case entity.InvoiceStatusVoid:
+		return "", apperror.Conflict("void invoices cannot take payments")
+	}
+	status := settledStatus(invoice, balance)
+	if err := checkLedgerTransition(invoice.Status, status); err != nil {
+		return "", err
+	}
+	return status, nil
+}
+
+func settledStatus(invoice entity.Invoice, balance entity.InvoiceBalance) string {
+	if balance.Due(invoice.Amount) <= 0 {
+		return entity.InvoiceStatusPaid
+	}
+	if startOfDay(time.Now()).After(startOfDay(invoice.DueDate)) {
+		return entity.InvoiceStatusOverdue
+	}
+	if balance.NetPaid()+balance.Credited > 0 {
+		return entity.InvoiceStatusPartiallyPaid
+	}
+	if invoice.Status == entity.InvoiceStatusPartiallyPaid || invoice.Status == entity.InvoiceStatusPaid {
+		return entity.InvoiceStatusPending
+	}
+	return invoice.Status
+}
+
+func toInvoicePaymentsResponse(invoice entity.Invoice) entity.InvoicePaymentsResponse {
+	res := entity.InvoicePaymentsResponse{}
+	res.InvoiceId = invoice.ID
+	res.Status = invoice.Status
+	res.Amount = invoice.Amount
+	balance := entity.NewInvoiceBalance(invoice)
+	res.AmountPaid = balance.NetPaid()
+	res.AmountCredited = balance.Credited
+	res.BalanceDue = balance.Due(invoice.Amount)
+	res.Payments = []entity.PaymentResponse{}
+	for _, v := range invoice.Payments {
+		res.Payments = append(res.Payments, toPaymentResponse(v))
+	}
+	return res
+}
+
+func toPaymentResponse(payment entity.Payment) entity.PaymentResponse {
+	res := entity.PaymentResponse{
+		ID:         payment.ID,
+		InvoiceId:  payment.InvoiceId,
+		Amount:     payment.Amount,
+		Method:     payment.Method,
+		Reference:  payment.Reference,
+		ReceivedAt: payment.ReceivedAt,
+		Refunds:    []entity.RefundResponse{},
+	}
+	for _, v := range payment.Refunds {
+		res.AmountRefunded += v.Amount
+		res.Refunds = append(res.Refunds, toRefundResponse(v))
+	}
+	return res
+}
+
+func toRefundResponse(refund entity.Refund) entity.RefundResponse {
+	return entity.RefundResponse{
+		ID:         refund.ID,
+		PaymentId:  refund.PaymentId,
+		Amount:     refund.Amount,
+		Reason:     refund.Reason,
+		RefundedAt: refund.RefundedAt,
+	}
+}
@@ -0,0 +1,291 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"next-learn-go/apperror"
+	"next-learn-go/entity"
+	"next-learn-go/repository"
+	"next-learn-go/tenant"
+	"next-learn-go/validator"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// maxPreviewCount bounds how many upcoming invoices a preview may list.
+const maxPreviewCount = 60
+
+type RecurringInvoiceUseCase interface {
+	GetRecurringInvoices(ctx context.Context) ([]entity.RecurringInvoiceResponse, error)
+	GetRecurringInvoiceById(ctx context.Context, recurringInvoiceId uuid.UUID) (entity.RecurringInvoiceResponse, error)
+	CreateRecurringInvoice(ctx context.Context, recurringInvoice entity.RecurringInvoice) (entity.RecurringInvoiceResponse, error)
+	UpdateRecurringInvoice(ctx context.Context, recurringInvoice entity.RecurringInvoice, recurringInvoiceId uuid.UUID) (entity.RecurringInvoiceResponse, error)
+	DeleteRecurringInvoice(ctx context.Context, recurringInvoiceId uuid.UUID) error
+	PreviewRecurringInvoice(ctx context.Context, recurringInvoiceId uuid.UUID, count int) (entity.RecurringInvoicePreviewResponse, error)
+	GenerateRecurringInvoices(ctx context.Context) (int, error)
+}
+
+type recurringInvoiceUseCase struct {
+	rr repository.RecurringInvoiceRepository
+	cr repository.CustomerRepository
+	rv validator.RecurringInvoiceValidator
+}
+
+func NewRecurringInvoiceUseCase(rr repository.RecurringInvoiceRepository, cr repository.CustomerRepository, rv validator.RecurringInvoiceValidator) RecurringInvoiceUseCase {
+	return &recurringInvoiceUseCase{rr, cr, rv}
+}
+
+func (ru *recurringInvoiceUseCase) GetRecurringInvoices(ctx context.Context) ([]entity.RecurringInvoiceResponse, error) {
+	ctx, span := tracer.Start(ctx, "RecurringInvoiceUseCase.GetRecurringInvoices")
+	defer span.End()
+
+	recurringInvoices := []entity.RecurringInvoice{}
+	if err := ru.rr.GetRecurringInvoices(ctx, &recurringInvoices); err != nil {
+		return nil, err
+	}
+	resRecurringInvoices := []entity.RecurringInvoiceResponse{}
+	for _, v := range recurringInvoices {
+		resRecurringInvoices = append(resRecurringInvoices, toRecurringInvoiceResponse(v))
+	}
+	return resRecurringInvoices, nil
+}
+
+func (ru *recurringInvoiceUseCase) GetRecurringInvoiceById(ctx context.Context, recurringInvoiceId uuid.UUID) (entity.RecurringInvoiceResponse, error) {
+	ctx, span := tracer.Start(ctx, "RecurringInvoiceUseCase.GetRecurringInvoiceById")
+	defer span.End()
+
+	recurringInvoice := entity.RecurringInvoice{}
+	if err := ru.rr.GetRecurringInvoiceById(ctx, &recurringInvoice, recurringInvoiceId); err != nil {
+		return entity.RecurringInvoiceResponse{}, err
+	}
+	return toRecurringInvoiceResponse(recurringInvoice), nil
+}
+
+func (ru *recurringInvoiceUseCase) CreateRecurringInvoice(ctx context.Context, recurringInvoice entity.RecurringInvoice) (entity.RecurringInvoiceResponse, error) {
+	ctx, span := tracer.Start(ctx, "RecurringInvoiceUseCase.CreateRecurringInvoice")
+	defer span.End()
+
+	if err := ru.rv.RecurringInvoiceValidate(recurringInvoice); err != nil {
+		return entity.RecurringInvoiceResponse{}, err
+	}
+	if err := ru.checkCustomer(ctx, recurringInvoice.CustomerId); err != nil {
+		return entity.RecurringInvoiceResponse{}, err
+	}
+	newRecurringInvoice := entity.RecurringInvoice{}
+	applyRecurringInvoice(&newRecurringInvoice, recurringInvoice)
+	// 作成より前の請求日はさかのぼって請求しない
+	newRecurringInvoice.NextDate = nextOccurrence(newRecurringInvoice, startOfDay(time.Now()))
+	if err := ru.rr.CreateRecurringInvoice(ctx, &newRecurringInvoice); err != nil {
+		return entity.RecurringInvoiceResponse{}, err
+	}
+	return toRecurringInvoiceResponse(newRecurringInvoice), nil
+}
+
+func (ru *recurringInvoiceUseCase) UpdateRecurringInvoice(ctx context.Context, recurringInvoice entity.RecurringInvoice, recurringInvoiceId uuid.UUID) (entity.RecurringInvoiceResponse, error) {
+	ctx, span := tracer.Start(ctx, "RecurringInvoiceUseCase.UpdateRecurringInvoice")
+	defer span.End()
+
+	if err := ru.rv.RecurringInvoiceValidate(recurringInvoice); err != nil {
+		return entity.RecurringInvoiceResponse{}, err
+	}
+	current := entity.RecurringInvoice{}
+	if err := ru.rr.GetRecurringInvoiceById(ctx, &current, recurringInvoiceId); err != nil {
+		return entity.RecurringInvoiceResponse{}, err
+	}
+	if err := ru.checkCustomer(ctx, recurringInvoice.CustomerId); err != nil {
+		return entity.RecurringInvoiceResponse{}, err
+	}
+	applyRecurringInvoice(&current, recurringInvoice)
+	// 請求済みの日付と今日より前の日付は、新しい予定でも請求しない
+	from := startOfDay(time.Now())
+	if !current.LastInvoiceDate.IsZero() {
+		from = later(from, startOfDay(current.LastInvoiceDate.Time).AddDate(0, 0, 1))
+	}
+	current.NextDate = nextOccurrence(current, from)
+	if err := ru.rr.UpdateRecurringInvoice(ctx, &current, recurringInvoiceId); err != nil {
+		return entity.RecurringInvoiceResponse{}, err
+	}
+	return toRecurringInvoiceResponse(current), nil
+}
+
+func (ru *recurringInvoiceUseCase) DeleteRecurringInvoice(ctx context.Context, recurringInvoiceId uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "RecurringInvoiceUseCase.DeleteRecurringInvoice")
+	defer span.End()
+
+	if err := ru.rr.DeleteRecurringInvoice(ctx, recurringInvoiceId); err != nil {
+		return err
+	}
+	return nil
+}
+
+// PreviewRecurringInvoice lists the next count invoices the recurring invoice
+// will create.
+func (ru *recurringInvoiceUseCase) PreviewRecurringInvoice(ctx context.Context, recurringInvoiceId uuid.UUID, count int) (entity.RecurringInvoicePreviewResponse, error) {
+	ctx, span := tracer.Start(ctx, "RecurringInvoiceUseCase.PreviewRecurringInvoice")
+	defer span.End()
+
+	if count < 1 || count > maxPreviewCount {
+		return entity.RecurringInvoicePreviewResponse{}, apperror.InvalidField("count", fmt.Sprintf("must be between 1 and %d", maxPreviewCount))
+	}
+	recurringInvoice := entity.RecurringInvoice{}
+	if err := ru.rr.GetRecurringInvoiceById(ctx, &recurringInvoice, recurringInvoiceId); err != nil {
+		return entity.RecurringInvoicePreviewResponse{}, err
+	}
+
+	res := entity.RecurringInvoicePreviewResponse{}
+	res.RecurringInvoiceId = recurringInvoice.ID
+	res.Occurrences = []entity.RecurringInvoiceOccurrence{}
+	// 生成時と同じ組み立てを使い、期日と金額も実際の請求書と一致させる
+	for len(res.Occurrences) < count && !recurringInvoice.NextDate.IsZero() {
+		invoice, next, err := buildRecurringInvoice(recurringInvoice)
+		if err != nil {
+			return entity.RecurringInvoicePreviewResponse{}, err
+		}
+		res.Occurrences = append(res.Occurrences, entity.RecurringInvoiceOccurrence{
+			Date:    invoice.Date,
+			DueDate: invoice.DueDate,
+			Amount:  invoice.Amount,
+		})
+		recurringInvoice.NextDate = next
+	}
+	return res, nil
+}
+
+// GenerateRecurringInvoices creates the invoices that are due from every
+// recurring invoice and returns how many were created. A recurring invoice
+// that fails is reported without holding up the others.
+func (ru *recurringInvoiceUseCase) GenerateRecurringInvoices(ctx context.Context) (int, error) {
+	ctx, span := tracer.Start(ctx, "RecurringInvoiceUseCase.GenerateRecurringInvoices")
+	defer span.End()
+
+	today := startOfDay(time.Now())
+	due := []entity.RecurringInvoice{}
+	if err := ru.rr.GetDueRecurringInvoices(ctx, &due, today); err != nil {
+		return 0, err
+	}
+	count := 0
+	errs := []error{}
+	for _, v := range due {
+		// 請求書とその履歴はひな形の組織に属する
+		orgCtx := tenant.WithOrganization(ctx, v.OrganizationId)
+		n, err := ru.rr.GenerateInvoices(orgCtx, v.ID, today, buildRecurringInvoice)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("recurring invoice %s: %w", v.ID, err))
+		}
+		count += n
+	}
+	return count, errors.Join(errs...)
+}
+
+func (ru *recurringInvoiceUseCase) checkCustomer(ctx context.Context, customerId uuid.UUID) error {
+	customer := entity.Customer{}
+	if err := ru.cr.GetCustomerById(ctx, &customer, customerId); err != nil {
+		if apperror.Is(err, apperror.KindNotFound) {
+			return apperror.InvalidField("customer_id", "customer does not exist")
+		}
+		return err
+	}
+	return nil
+}
+
+// applyRecurringInvoice copies the fields a client may set onto dst.
+func applyRecurringInvoice(dst *entity.RecurringInvoice, src entity.RecurringInvoice) {
+	dst.CustomerId = src.CustomerId
+	dst.Description = src.Description
+	dst.Amount = src.Amount
+	dst.TaxRate = src.TaxRate
+	dst.Frequency = src.Frequency
+	dst.DayOfMonth = src.DayOfMonth
+	dst.StartDate = startOfDay(src.StartDate)
+	dst.EndDate = bun.NullTime{}
+	if !src.EndDate.IsZero() {
+		dst.EndDate = bun.NullTime{Time: startOfDay(src.EndDate.Time)}
+	}
+}
+
+// buildRecurringInvoice builds the invoice recurringInvoice bills on its next
+// date and returns the date after that. The invoice is issued as pending with
+// the customer's payment terms and a single line for the amount.
+func buildRecurringInvoice(recurringInvoice entity.RecurringInvoice) (entity.Invoice, bun.NullTime, error) {
+	date := recurringInvoice.NextDate.Time
+	invoice := entity.Invoice{
+		OrganizationId:     recurringInvoice.OrganizationId,
+		CustomerId:         recurringInvoice.CustomerId,
+		Customer:           recurringInvoice.Customer,
+		Status:             entity.InvoiceStatusPending,
+		Date:               date,
+		RecurringInvoiceId: uuid.NullUUID{UUID: recurringInvoice.ID, Valid: true},
+		RecurrenceDate:     bun.NullTime{Time: date},
+		Items: []entity.InvoiceItem{{
+			Description: recurringInvoice.Description,
+			Quantity:    1,
+			UnitPrice:   recurringInvoice.Amount,
+			TaxRate:     recurringInvoice.TaxRate,
+		}},
+	}
+	if err := applyPaymentTerms(&invoice, recurringInvoice.Customer.PaymentTerms); err != nil {
+		return entity.Invoice{}, bun.NullTime{}, err
+	}
+	calculateInvoiceTotals(&invoice)
+	return invoice, nextOccurrence(recurringInvoice, date.AddDate(0, 0, 1)), nil
+}
+
+// nextOccurrence returns the first billing date of recurringInvoice on or
+// after from, or null once its schedule has ended. Billing dates fall on
+// DayOfMonth, or on the last day of shorter months, every one, three or twelve
+// months counting from the month of StartDate.
+func nextOccurrence(recurringInvoice entity.RecurringInvoice, from time.Time) bun.NullTime {
+	start := startOfDay(recurringInvoice.StartDate)
+	from = later(startOfDay(from), start)
+	months := entity.RecurrenceMonths[recurringInvoice.Frequency]
+	if months == 0 {
+		return bun.NullTime{}
+	}
+	// from の前の周期から数え始め、月末の丸めで from より前になる日を読み飛ばす
+	elapsed := (from.Year()-start.Year())*12 + int(from.Month()-start.Month())
+	for k := max(elapsed/months-1, 0); ; k++ {
+		month := time.Date(start.Year(), start.Month()+time.Month(k*months), 1, 0, 0, 0, 0, time.UTC)
+		lastDay := month.AddDate(0, 1, -1).Day()
+		date := time.Date(month.Year(), month.Month(), min(recurringInvoice.DayOfMonth, lastDay), 0, 0, 0, 0, time.UTC)
+		if date.Before(from) {
+			continue
+		}
+		if !recurringInvoice.EndDate.IsZero() && date.After(startOfDay(recurringInvoice.EndDate.Time)) {
+			return bun.NullTime{}
+		}
+		return bun.NullTime{Time: date}
+	}
+}
+
+func later(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func toRecurringInvoiceResponse(recurringInvoice entity.RecurringInvoice) entity.RecurringInvoiceResponse {
+	res := entity.RecurringInvoiceResponse{}
+	res.ID = recurringInvoice.ID
+	res.CustomerId = recurringInvoice.CustomerId
+	res.Description = recurringInvoice.Description
+	res.Amount = recurringInvoice.Amount
+	res.TaxRate = recurringInvoice.TaxRate
+	res.Frequency = recurringInvoice.Frequency
+	res.DayOfMonth = recurringInvoice.DayOfMonth
+	res.StartDate = recurringInvoice.StartDate
+	res.EndDate = nullTimePtr(recurringInvoice.EndDate)
+	res.NextDate = nullTimePtr(recurringInvoice.NextDate)
+	res.LastInvoiceDate = nullTimePtr(recurringInvoice.LastInvoiceDate)
+	return res
+}
+
+func nullTimePtr(t bun.NullTime) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t.Time
+}
@@ -4,24 +4,78 @@ import (
 	"context"
 	"next-learn-go/entity"
 	"next-learn-go/repository"
+	"next-learn-go/validator"
+	"time"
 )
 
 type RevenueUseCase interface {
-	GetAllRevenues() ([]entity.Revenue, error)
+	GetAllRevenues(ctx context.Context) ([]entity.Revenue, error)
+	GetRevenueAggregate(ctx context.Context, query entity.RevenueQuery) (entity.RevenueAggregateResponse, error)
 }
 
 type revenueUseCase struct {
 	rr repository.RevenueRepository
+	rv validator.RevenueValidator
 }
 
-func NewRevenueUseCase(rr repository.RevenueRepository) RevenueUseCase {
-	return &revenueUseCase{rr}
+func NewRevenueUseCase(rr repository.RevenueRepository, rv validator.RevenueValidator) RevenueUseCase {
+	return &revenueUseCase{rr, rv}
 }
 
-func (ru *revenueUseCase) GetAllRevenues() ([]entity.Revenue, error) {
-	revenues := []entity.Revenue{}
-	if err := ru.rr.GetAllRevenues(context.Background(), &revenues); err != nil {
+// GetAllRevenues returns the trailing twelve months in the shape the
+// dashboard chart was built against.
+func (ru *revenueUseCase) GetAllRevenues(ctx context.Context) ([]entity.Revenue, error) {
+	ctx, span := tracer.Start(ctx, "RevenueUseCase.GetAllRevenues")
+	defer span.End()
+
+	now := time.Now().UTC()
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	from := time.Date(now.Year(), now.Month()-11, 1, 0, 0, 0, 0, time.UTC)
+
+	periods := []entity.RevenuePeriod{}
+	query := entity.RevenueQuery{From: from, To: to, Granularity: entity.GranularityMonth}
+	if err := ru.rr.GetRevenuePeriods(ctx, &periods, query); err != nil {
 		return nil, err
 	}
+
+	revenues := []entity.Revenue{}
+	for _, v := range periods {
+		r := entity.Revenue{}
+		r.Month = v.PeriodStart.Format("Jan")
+		// 請求書の金額はセント単位、グラフはドル単位
+		r.Revenue = v.Revenue / 100
+		revenues = append(revenues, r)
+	}
 	return revenues, nil
 }
+
+func (ru *revenueUseCase) GetRevenueAggregate(ctx context.Context, query entity.RevenueQuery) (entity.RevenueAggregateResponse, error) {
+	ctx, span := tracer.Start(ctx, "RevenueUseCase.GetRevenueAggregate")
+	defer span.End()
+
+	if err := ru.rv.RevenueQueryValidate(query); err != nil {
+		return entity.RevenueAggregateResponse{}, err
+	}
+	periods := []entity.RevenuePeriod{}
+	if err := ru.rr.GetRevenuePeriods(ctx, &periods, query); err != nil {
+		return entity.RevenueAggregateResponse{}, err
+	}
+
+	resRevenue := entity.RevenueAggregateResponse{}
+	resRevenue.From = query.From.Format("2006-01-02")
+	resRevenue.To = query.To.Format("2006-01-02")
+	resRevenue.Granularity = query.Granularity
+	resRevenue.Periods = []entity.RevenuePeriodResponse{}
+	for _, v := range periods {
+		p := entity.RevenuePeriodResponse{}
+		p.PeriodStart = v.PeriodStart.Format("2006-01-02")
+		p.Revenue = v.Revenue
+		p.Credited = v.Credited
+		p.Refunded = v.Refunded
+		resRevenue.Total += v.Revenue
+		resRevenue.Credited += v.Credited
+		resRevenue.Refunded += v.Refunded
+		resRevenue.Periods = append(resRevenue.Periods, p)
+	}
+	return resRevenue, nil
+}
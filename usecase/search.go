@@ -0,0 +1,128 @@
+package usecase
+
+import (
+	"context"
+	"html"
+	"next-learn-go/entity"
+	"next-learn-go/repository"
+	"next-learn-go/validator"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+type SearchUseCase interface {
+	Search(ctx context.Context, query entity.SearchQuery) (entity.SearchResponse, error)
+}
+
+type searchUseCase struct {
+	sr repository.SearchRepository
+	sv validator.SearchValidator
+}
+
+func NewSearchUseCase(sr repository.SearchRepository, sv validator.SearchValidator) SearchUseCase {
+	return &searchUseCase{sr, sv}
+}
+
+// Search returns customers and invoices matching query, most relevant first.
+func (su *searchUseCase) Search(ctx context.Context, query entity.SearchQuery) (entity.SearchResponse, error) {
+	ctx, span := tracer.Start(ctx, "SearchUseCase.Search")
+	defer span.End()
+
+	query.Query = strings.TrimSpace(query.Query)
+	if err := su.sv.SearchQueryValidate(query); err != nil {
+		return entity.SearchResponse{}, err
+	}
+
+	customers := []entity.CustomerSearchResult{}
+	if err := su.sr.SearchCustomers(ctx, &customers, query.Query, query.Limit); err != nil {
+		return entity.SearchResponse{}, err
+	}
+	invoices := []entity.InvoiceSearchResult{}
+	if err := su.sr.SearchInvoices(ctx, &invoices, query.Query, query.Limit); err != nil {
+		return entity.SearchResponse{}, err
+	}
+
+	terms := searchTerms(query.Query)
+	hits := []entity.SearchHit{}
+	for i := range customers {
+		customer := &customers[i]
+		hits = append(hits, entity.SearchHit{
+			Type:  entity.SearchHitCustomer,
+			ID:    customer.ID,
+			Score: customer.Score,
+			Highlights: highlights(terms, map[string]string{
+				"name":  customer.Name,
+				"email": customer.Email,
+			}),
+			Customer: customer,
+		})
+	}
+	for i := range invoices {
+		invoice := &invoices[i]
+		hits = append(hits, entity.SearchHit{
+			Type:  entity.SearchHitInvoice,
+			ID:    invoice.ID,
+			Score: invoice.Score,
+			Highlights: highlights(terms, map[string]string{
+				"customer_name":  invoice.CustomerName,
+				"customer_email": invoice.CustomerEmail,
+				"matched_items":  invoice.MatchedItems,
+			}),
+			Invoice: invoice,
+		})
+	}
+	// 顧客と請求書を関連度順に混ぜて上位だけを返す
+	sort.SliceStable(hits, func(i, j int) bool {
+		return hits[i].Score > hits[j].Score
+	})
+	if len(hits) > query.Limit {
+		hits = hits[:query.Limit]
+	}
+	return entity.SearchResponse{Query: query.Query, Hits: hits}, nil
+}
+
+// searchTerms splits query into the words to highlight, dropping the quotes
+// and operators of the web search syntax.
+func searchTerms(query string) []string {
+	terms := []string{}
+	for _, term := range strings.Fields(query) {
+		term = strings.Trim(term, `"-`)
+		if term != "" && !strings.EqualFold(term, "or") {
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// highlights wraps the case-insensitive occurrences of terms in each field in
+// <mark>. The text is HTML-escaped so that it is safe to render as HTML.
+func highlights(terms []string, fields map[string]string) map[string]string {
+	res := map[string]string{}
+	if len(terms) == 0 {
+		return res
+	}
+	quoted := make([]string, len(terms))
+	for i, term := range terms {
+		quoted[i] = regexp.QuoteMeta(term)
+	}
+	pattern := regexp.MustCompile("(?i)" + strings.Join(quoted, "|"))
+	for name, text := range fields {
+		matches := pattern.FindAllStringIndex(text, -1)
+		if len(matches) == 0 {
+			continue
+		}
+		var b strings.Builder
+		last := 0
+		for _, m := range matches {
+			b.WriteString(html.EscapeString(text[last:m[0]]))
+			b.WriteString("<mark>")
+			b.WriteString(html.EscapeString(text[m[0]:m[1]]))
+			b.WriteString("</mark>")
+			last = m[1]
+		}
+		b.WriteString(html.EscapeString(text[last:]))
+		res[name] = b.String()
+	}
+	return res
+}
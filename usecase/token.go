@@ -0,0 +1,79 @@
+package usecase
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultAccessTokenTTL  = 15 * time.Minute
+	defaultRefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+// JwtCustomClaims are the claims carried by access tokens. Role is the user's
+// role in OrganizationId, the tenant every request is scoped to. SessionId is
+// the refresh token family the access token was issued from, so revoking the
+// family also invalidates access tokens that have not expired yet.
+type JwtCustomClaims struct {
+	UserId         uuid.UUID `json:"user_id"`
+	Email          string    `json:"email"`
+	OrganizationId uuid.UUID `json:"org_id"`
+	Role           string    `json:"role"`
+	SessionId      uuid.UUID `json:"sid"`
+	jwt.RegisteredClaims
+}
+
+func accessTokenTTL() time.Duration {
+	return durationFromEnv("ACCESS_TOKEN_TTL", defaultAccessTokenTTL)
+}
+
+func refreshTokenTTL() time.Duration {
+	return durationFromEnv("REFRESH_TOKEN_TTL", defaultRefreshTokenTTL)
+}
+
+func durationFromEnv(key string, fallback time.Duration) time.Duration {
+	d, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || d <= 0 {
+		return fallback
+	}
+	return d
+}
+
+func signAccessToken(claims JwtCustomClaims) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(accessTokenTTL())
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		Subject:   claims.UserId.String(),
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(expiresAt),
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	tokenString, err := token.SignedString([]byte(os.Getenv("SECRET")))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return tokenString, expiresAt, nil
+}
+
+// newRefreshToken returns an opaque random token and the hash stored in the
+// database. The raw token is only ever handed to the client.
+func newRefreshToken() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	return token, hashRefreshToken(token), nil
+}
+
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,7 @@
+package usecase
+
+import "go.opentelemetry.io/otel"
+
+// tracer starts a span around every usecase method, between the HTTP server
+// span and the spans of the queries the method runs.
+var tracer = otel.Tracer("next-learn-go/usecase")
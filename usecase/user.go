@@ -2,41 +2,52 @@ package usecase
 
 import (
 	"context"
-	"errors"
+	"time"
 
+	"next-learn-go/apperror"
 	"next-learn-go/entity"
+	"next-learn-go/logger"
 	"next-learn-go/repository"
 	"next-learn-go/validator"
-	"os"
-	"time"
 
-	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type UserUseCase interface {
-	SignUp(user entity.User) (entity.UserResponse, error)
-	Login(user entity.User) (entity.LoginResponse, error)
-	GetUserById(userId uint) (entity.UserResponse, error)
-	GetUserByEmail(email string) (entity.UserResponse, error)
+	SignUp(ctx context.Context, req entity.SignUpRequest) (entity.UserResponse, error)
+	Login(ctx context.Context, user entity.User, organizationId uuid.UUID) (entity.LoginResponse, error)
+	RefreshToken(ctx context.Context, refreshToken string) (entity.LoginResponse, error)
+	Logout(ctx context.Context, sessionId uuid.UUID) error
+	GetSessionRole(ctx context.Context, sessionId, organizationId, userId uuid.UUID) (string, error)
+	GetUserById(ctx context.Context, organizationId, userId uuid.UUID) (entity.UserResponse, error)
+	GetUserByEmail(ctx context.Context, organizationId uuid.UUID, email string) (entity.UserResponse, error)
 }
 
 type userUseCase struct {
 	ur repository.UserRepository
+	or repository.OrganizationRepository
+	tr repository.TokenRepository
 	uv validator.UserValidator
 }
 
-func NewUserUseCase(ur repository.UserRepository, uv validator.UserValidator) UserUseCase {
-	return &userUseCase{ur, uv}
+func NewUserUseCase(ur repository.UserRepository, or repository.OrganizationRepository, tr repository.TokenRepository, uv validator.UserValidator) UserUseCase {
+	return &userUseCase{ur, or, tr, uv}
 }
 
-func (uu *userUseCase) SignUp(user entity.User) (entity.UserResponse, error) {
+func (uu *userUseCase) SignUp(ctx context.Context, req entity.SignUpRequest) (entity.UserResponse, error) {
+	ctx, span := tracer.Start(ctx, "UserUseCase.SignUp")
+	defer span.End()
+
+	user := entity.User{Name: req.Name, Email: req.Email, Password: req.Password}
 	if err := uu.uv.UserValidate(user); err != nil {
 		return entity.UserResponse{}, err
 	}
 
-	if err := uu.ur.GetUserByEmail(context.Background(), &entity.User{}, user.Email); err == nil {
-		return entity.UserResponse{}, errors.New("email already exists")
+	if err := uu.ur.GetUserByEmail(ctx, &entity.User{}, user.Email); err == nil {
+		return entity.UserResponse{}, apperror.Conflict("email already exists")
+	} else if !apperror.Is(err, apperror.KindNotFound) {
+		return entity.UserResponse{}, err
 	}
 
 	hash, err := bcrypt.GenerateFromPassword([]byte(user.Password), 10)
@@ -44,8 +55,14 @@ func (uu *userUseCase) SignUp(user entity.User) (entity.UserResponse, error) {
 		return entity.UserResponse{}, err
 	}
 
+	// 新規登録したユーザーは自分の組織の管理者になる
+	organizationName := req.OrganizationName
+	if organizationName == "" {
+		organizationName = user.Name + "'s organization"
+	}
 	newUser := entity.User{Name: user.Name, Email: user.Email, Password: string(hash)}
-	if err := uu.ur.CreateUser(context.Background(), &newUser); err != nil {
+	organization := entity.Organization{Name: organizationName}
+	if err := uu.ur.CreateUser(ctx, &newUser, &organization); err != nil {
 		return entity.UserResponse{}, err
 	}
 
@@ -53,68 +70,237 @@ func (uu *userUseCase) SignUp(user entity.User) (entity.UserResponse, error) {
 		ID:    newUser.ID,
 		Name:  newUser.Name,
 		Email: newUser.Email,
+		Role:  entity.RoleAdmin,
 	}
 
 	return resUser, nil
 }
 
-func (uu *userUseCase) Login(user entity.User) (entity.LoginResponse, error) {
+func (uu *userUseCase) Login(ctx context.Context, user entity.User, organizationId uuid.UUID) (entity.LoginResponse, error) {
+	ctx, span := tracer.Start(ctx, "UserUseCase.Login")
+	defer span.End()
+
 	if err := uu.uv.UserValidate(user); err != nil {
 		return entity.LoginResponse{}, err
 	}
 	storedUser := entity.User{}
-	ctx := context.Background()
 	if err := uu.ur.GetUserByEmail(ctx, &storedUser, user.Email); err != nil {
+		if apperror.Is(err, apperror.KindNotFound) {
+			return entity.LoginResponse{}, apperror.Unauthorized("invalid email or password")
+		}
 		return entity.LoginResponse{}, err
 	}
 	err := bcrypt.CompareHashAndPassword([]byte(storedUser.Password), []byte(user.Password))
+	if err != nil {
+		return entity.LoginResponse{}, apperror.Wrap(apperror.KindUnauthorized, err, "invalid email or password")
+	}
+
+	member, err := uu.loginMembership(ctx, storedUser.ID, organizationId)
 	if err != nil {
 		return entity.LoginResponse{}, err
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"user_id": storedUser.ID,
-		"exp":     time.Now().Add(time.Hour * 12).Unix(),
-	})
 
-	tokenString, err := token.SignedString([]byte(os.Getenv("SECRET")))
+	// ログインごとに新しいトークンファミリー（セッション）を開始する
+	refreshToken, tokenHash, err := newRefreshToken()
+	if err != nil {
+		return entity.LoginResponse{}, err
+	}
+	storedToken := entity.RefreshToken{
+		UserId:         storedUser.ID,
+		OrganizationId: member.OrganizationId,
+		FamilyId:       uuid.New(),
+		TokenHash:      tokenHash,
+		ExpiresAt:      time.Now().Add(refreshTokenTTL()),
+	}
+	if err := uu.tr.CreateRefreshToken(ctx, &storedToken); err != nil {
+		return entity.LoginResponse{}, err
+	}
+	logger.FromContext(ctx).Info("user logged in",
+		"user_id", storedUser.ID, "organization_id", member.OrganizationId, "session_id", storedToken.FamilyId)
+
+	return uu.newLoginResponse(storedUser, member, storedToken.FamilyId, refreshToken)
+}
+
+// loginMembership picks the organization the session is bound to: the one
+// requested, or the user's oldest membership when none is given.
+func (uu *userUseCase) loginMembership(ctx context.Context, userId, organizationId uuid.UUID) (entity.OrganizationMember, error) {
+	if organizationId != uuid.Nil {
+		member := entity.OrganizationMember{}
+		if err := uu.or.GetMembership(ctx, &member, organizationId, userId); err != nil {
+			if apperror.Is(err, apperror.KindNotFound) {
+				return entity.OrganizationMember{}, apperror.Forbidden("you are not a member of this organization")
+			}
+			return entity.OrganizationMember{}, err
+		}
+		return member, nil
+	}
+
+	members := []entity.OrganizationMember{}
+	if err := uu.or.GetMembershipsByUser(ctx, &members, userId); err != nil {
+		return entity.OrganizationMember{}, err
+	}
+	if len(members) == 0 {
+		return entity.OrganizationMember{}, apperror.Forbidden("you do not belong to any organization")
+	}
+	return members[0], nil
+}
+
+func (uu *userUseCase) RefreshToken(ctx context.Context, refreshToken string) (entity.LoginResponse, error) {
+	ctx, span := tracer.Start(ctx, "UserUseCase.RefreshToken")
+	defer span.End()
+
+	if refreshToken == "" {
+		return entity.LoginResponse{}, apperror.InvalidField("refresh_token", "refresh_token is required")
+	}
+	storedToken := entity.RefreshToken{}
+	if err := uu.tr.GetRefreshTokenByHash(ctx, &storedToken, hashRefreshToken(refreshToken)); err != nil {
+		if apperror.Is(err, apperror.KindNotFound) {
+			return entity.LoginResponse{}, apperror.Unauthorized("invalid refresh token")
+		}
+		return entity.LoginResponse{}, err
+	}
+
+	// ローテーション済みのトークンが再利用された場合は漏洩とみなしてファミリーごと失効させる。
+	// クライアントが切断しても失効は最後まで行う
+	if !storedToken.RevokedAt.IsZero() || storedToken.ReplacedBy.Valid {
+		logger.FromContext(ctx).Warn("refresh token reused, revoking session",
+			"user_id", storedToken.UserId, "session_id", storedToken.FamilyId)
+		if err := uu.tr.RevokeTokenFamily(context.WithoutCancel(ctx), storedToken.FamilyId); err != nil {
+			return entity.LoginResponse{}, err
+		}
+		return entity.LoginResponse{}, apperror.Unauthorized("refresh token has been revoked")
+	}
+	if time.Now().After(storedToken.ExpiresAt) {
+		return entity.LoginResponse{}, apperror.Unauthorized("refresh token has expired")
+	}
+
+	user := entity.User{}
+	if err := uu.ur.GetUserById(ctx, &user, storedToken.UserId); err != nil {
+		if apperror.Is(err, apperror.KindNotFound) {
+			return entity.LoginResponse{}, apperror.Unauthorized("invalid refresh token")
+		}
+		return entity.LoginResponse{}, err
+	}
+	// 組織から外されたユーザーはセッションを延長できない
+	member := entity.OrganizationMember{}
+	if err := uu.or.GetMembership(ctx, &member, storedToken.OrganizationId, storedToken.UserId); err != nil {
+		if apperror.Is(err, apperror.KindNotFound) {
+			if err := uu.tr.RevokeTokenFamily(ctx, storedToken.FamilyId); err != nil {
+				return entity.LoginResponse{}, err
+			}
+			return entity.LoginResponse{}, apperror.Unauthorized("you are no longer a member of this organization")
+		}
+		return entity.LoginResponse{}, err
+	}
+
+	newToken, tokenHash, err := newRefreshToken()
+	if err != nil {
+		return entity.LoginResponse{}, err
+	}
+	rotatedToken := entity.RefreshToken{
+		UserId:         storedToken.UserId,
+		OrganizationId: storedToken.OrganizationId,
+		FamilyId:       storedToken.FamilyId,
+		TokenHash:      tokenHash,
+		ExpiresAt:      time.Now().Add(refreshTokenTTL()),
+	}
+	if err := uu.tr.RotateRefreshToken(ctx, storedToken.ID, &rotatedToken); err != nil {
+		if apperror.Is(err, apperror.KindConflict) {
+			if err := uu.tr.RevokeTokenFamily(context.WithoutCancel(ctx), storedToken.FamilyId); err != nil {
+				return entity.LoginResponse{}, err
+			}
+			return entity.LoginResponse{}, apperror.Unauthorized("refresh token has been revoked")
+		}
+		return entity.LoginResponse{}, err
+	}
+
+	return uu.newLoginResponse(user, member, storedToken.FamilyId, newToken)
+}
+
+func (uu *userUseCase) Logout(ctx context.Context, sessionId uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "UserUseCase.Logout")
+	defer span.End()
+
+	if err := uu.tr.RevokeTokenFamily(ctx, sessionId); err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetSessionRole returns the role the user currently has in the organization
+// of the session, so that role changes apply without waiting for the access
+// token to expire. Revoked sessions and removed members are unauthorized.
+func (uu *userUseCase) GetSessionRole(ctx context.Context, sessionId, organizationId, userId uuid.UUID) (string, error) {
+	ctx, span := tracer.Start(ctx, "UserUseCase.GetSessionRole")
+	defer span.End()
+
+	role, err := uu.tr.GetActiveSessionRole(ctx, sessionId, organizationId, userId)
+	if err != nil {
+		return "", err
+	}
+	if role == "" {
+		return "", apperror.Unauthorized("session has been revoked")
+	}
+	return role, nil
+}
+
+func (uu *userUseCase) newLoginResponse(user entity.User, member entity.OrganizationMember, sessionId uuid.UUID, refreshToken string) (entity.LoginResponse, error) {
+	tokenString, expiresAt, err := signAccessToken(JwtCustomClaims{
+		UserId:         user.ID,
+		Email:          user.Email,
+		OrganizationId: member.OrganizationId,
+		Role:           member.Role,
+		SessionId:      sessionId,
+	})
 	if err != nil {
 		return entity.LoginResponse{}, err
 	}
 
 	resLogin := entity.LoginResponse{
-		ID:    storedUser.ID,
-		Email: storedUser.Email,
-		Token: tokenString,
+		ID:             user.ID,
+		Email:          user.Email,
+		OrganizationId: member.OrganizationId,
+		Role:           member.Role,
+		Token:          tokenString,
+		ExpiresAt:      expiresAt,
+		RefreshToken:   refreshToken,
 	}
 	return resLogin, nil
 }
 
-func (uu *userUseCase) GetUserById(userId uint) (entity.UserResponse, error) {
+func (uu *userUseCase) GetUserById(ctx context.Context, organizationId, userId uuid.UUID) (entity.UserResponse, error) {
+	ctx, span := tracer.Start(ctx, "UserUseCase.GetUserById")
+	defer span.End()
+
 	user := entity.User{}
-	ctx := context.Background()
 	if err := uu.ur.GetUserById(ctx, &user, userId); err != nil {
 		return entity.UserResponse{}, err
 	}
-	resUser := entity.UserResponse{
-		ID:       user.ID,
-		Name:     user.Name,
-		Email:    user.Email,
-		Password: user.Password,
-	}
-	return resUser, nil
+	return uu.toUserResponse(ctx, organizationId, user)
 }
 
-func (uu *userUseCase) GetUserByEmail(email string) (entity.UserResponse, error) {
+func (uu *userUseCase) GetUserByEmail(ctx context.Context, organizationId uuid.UUID, email string) (entity.UserResponse, error) {
+	ctx, span := tracer.Start(ctx, "UserUseCase.GetUserByEmail")
+	defer span.End()
+
 	user := entity.User{}
-	ctx := context.Background()
 	if err := uu.ur.GetUserByEmail(ctx, &user, email); err != nil {
 		return entity.UserResponse{}, err
 	}
+	return uu.toUserResponse(ctx, organizationId, user)
+}
+
+func (uu *userUseCase) toUserResponse(ctx context.Context, organizationId uuid.UUID, user entity.User) (entity.UserResponse, error) {
+	member := entity.OrganizationMember{}
+	if err := uu.or.GetMembership(ctx, &member, organizationId, user.ID); err != nil {
+		return entity.UserResponse{}, err
+	}
 	resUser := entity.UserResponse{
 		ID:       user.ID,
 		Name:     user.Name,
 		Email:    user.Email,
 		Password: user.Password,
+		Role:     member.Role,
 	}
 	return resUser, nil
 }
@@ -0,0 +1,33 @@
+package validator
+
+import (
+	"next-learn-go/apperror"
+	"next-learn-go/entity"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+type CreditNoteValidator interface {
+	CreditNoteValidate(creditNote entity.CreditNote) error
+}
+
+type creditNoteValidator struct{}
+
+func NewCreditNoteValidator() CreditNoteValidator {
+	return &creditNoteValidator{}
+}
+
+func (cv *creditNoteValidator) CreditNoteValidate(creditNote entity.CreditNote) error {
+	return apperror.FromValidation(validation.ValidateStruct(&creditNote,
+		validation.Field(
+			&creditNote.Amount,
+			validation.Required.Error("amount is required"),
+			validation.Min(1).Error("amount must be positive"),
+		),
+		validation.Field(
+			&creditNote.Reason,
+			validation.Required.Error("reason is required"),
+			validation.RuneLength(1, 255).Error("limited max 255 char"),
+		),
+	))
+}
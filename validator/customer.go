@@ -0,0 +1,43 @@
+package validator
+
+import (
+	"next-learn-go/apperror"
+	"next-learn-go/entity"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+)
+
+type CustomerValidator interface {
+	CustomerValidate(customer entity.Customer) error
+}
+
+type customerValidator struct{}
+
+func NewCustomerValidator() CustomerValidator {
+	return &customerValidator{}
+}
+
+func (cv *customerValidator) CustomerValidate(customer entity.Customer) error {
+	return apperror.FromValidation(validation.ValidateStruct(&customer,
+		validation.Field(
+			&customer.Name,
+			validation.Required.Error("name is required"),
+			validation.RuneLength(1, 45).Error("limited max 45 char"),
+		),
+		validation.Field(
+			&customer.Email,
+			validation.Required.Error("email is required"),
+			validation.RuneLength(1, 255).Error("limited max 255 char"),
+			is.Email.Error("is not valid email format"),
+		),
+		validation.Field(
+			&customer.ImageUrl,
+			validation.RuneLength(0, 255).Error("limited max 255 char"),
+		),
+		validation.Field(
+			&customer.PaymentTerms,
+			validation.In(entity.PaymentTermsNet15, entity.PaymentTermsNet30, entity.PaymentTermsNet60).Error("payment_terms must be net_15, net_30 or net_60"),
+		),
+	))
+}
@@ -1,13 +1,25 @@
 package validator
 
 import (
+	"errors"
+	"next-learn-go/apperror"
 	"next-learn-go/entity"
+	"strings"
 
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 )
 
+var invoiceStatuses = func() []interface{} {
+	statuses := make([]interface{}, len(entity.InvoiceStatuses))
+	for i, status := range entity.InvoiceStatuses {
+		statuses[i] = status
+	}
+	return statuses
+}()
+
 type InvoiceValidator interface {
 	InvoiceValidate(invoice entity.Invoice) error
+	InvoiceFilterValidate(filter entity.InvoiceFilter) error
 }
 
 type invoiceValidator struct{}
@@ -17,19 +29,94 @@ func NewInvoiceValidator() InvoiceValidator {
 }
 
 func (tv *invoiceValidator) InvoiceValidate(invoice entity.Invoice) error {
-	return validation.ValidateStruct(&invoice,
+	return apperror.FromValidation(validation.ValidateStruct(&invoice,
 		validation.Field(
 			&invoice.CustomerId,
 			validation.Required.Error("CustomerId is required"),
 		),
 		validation.Field(
-			&invoice.Amount,
-			validation.Required.Error("Amount is required"),
+			&invoice.Status,
+			validation.In(invoiceStatuses...).Error("Status must be one of "+strings.Join(entity.InvoiceStatuses, ", ")),
 		),
 		validation.Field(
-			&invoice.Status,
-			validation.Required.Error("Status is required"),
-			validation.In(invoice.Status, "pending", "paid").Error("Status must be pending or paid"),
+			&invoice.PaymentTerms,
+			validation.In(entity.PaymentTermsNet15, entity.PaymentTermsNet30, entity.PaymentTermsNet60, entity.PaymentTermsCustom).
+				Error("PaymentTerms must be net_15, net_30, net_60 or custom"),
+		),
+		validation.Field(
+			&invoice.DueDate,
+			validation.When(invoice.PaymentTerms == entity.PaymentTermsCustom, validation.Required.Error("DueDate is required for custom payment terms")),
+		),
+		validation.Field(
+			&invoice.Items,
+			validation.Required.Error("Items is required"),
+			validation.Each(validation.By(tv.invoiceItemValidate)),
+		),
+	))
+}
+
+func (tv *invoiceValidator) invoiceItemValidate(value interface{}) error {
+	item, ok := value.(entity.InvoiceItem)
+	if !ok {
+		return validation.NewInternalError(errors.New("invalid invoice item"))
+	}
+	return validation.ValidateStruct(&item,
+		validation.Field(
+			&item.Description,
+			validation.Required.Error("Description is required"),
+			validation.RuneLength(1, 255).Error("limited max 255 char"),
+		),
+		validation.Field(
+			&item.Quantity,
+			validation.Required.Error("Quantity is required"),
+			validation.Min(1).Error("Quantity must be at least 1"),
+		),
+		validation.Field(
+			&item.UnitPrice,
+			validation.Min(0).Error("UnitPrice must not be negative"),
+		),
+		validation.Field(
+			&item.TaxRate,
+			validation.Min(0.0).Error("TaxRate must not be negative"),
+			validation.Max(100.0).Error("TaxRate must not exceed 100"),
 		),
 	)
 }
+
+func (tv *invoiceValidator) InvoiceFilterValidate(filter entity.InvoiceFilter) error {
+	return apperror.FromValidation(validation.Errors{
+		"status": validation.Validate(filter.Status,
+			validation.In(invoiceStatuses...).Error("status must be one of "+strings.Join(entity.InvoiceStatuses, ", ")),
+		),
+		"amount_min": validation.Validate(filter.AmountMin,
+			validation.Min(0).Error("amount_min must not be negative"),
+		),
+		"amount_max": validation.Validate(filter.AmountMax,
+			validation.By(func(value interface{}) error {
+				if filter.AmountMin != nil && filter.AmountMax != nil && *filter.AmountMax < *filter.AmountMin {
+					return errors.New("amount_max must not be less than amount_min")
+				}
+				return nil
+			}),
+		),
+		"date_to": validation.Validate(filter.DateTo,
+			validation.By(func(value interface{}) error {
+				if !filter.DateFrom.IsZero() && !filter.DateTo.IsZero() && filter.DateTo.Before(filter.DateFrom) {
+					return errors.New("date_to must not be before date_from")
+				}
+				return nil
+			}),
+		),
+		"sort": validation.Validate(filter.Sort,
+			validation.Each(validation.By(func(value interface{}) error {
+				field, _ := value.(entity.SortField)
+				switch field.Field {
+				case entity.InvoiceSortDate, entity.InvoiceSortDueDate, entity.InvoiceSortAmount, entity.InvoiceSortStatus,
+					entity.InvoiceSortName, entity.InvoiceSortEmail, entity.InvoiceSortNumber:
+					return nil
+				}
+				return errors.New("sort must be a comma separated list of number, date, due_date, amount, status, name or email, each optionally prefixed with -")
+			})),
+		),
+	}.Filter())
+}
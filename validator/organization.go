@@ -0,0 +1,45 @@
+package validator
+
+import (
+	"next-learn-go/apperror"
+	"next-learn-go/entity"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+)
+
+type OrganizationValidator interface {
+	OrganizationMemberValidate(req entity.AddOrganizationMemberRequest) error
+	OrganizationRoleValidate(req entity.UpdateUserRoleRequest) error
+}
+
+type organizationValidator struct{}
+
+func NewOrganizationValidator() OrganizationValidator {
+	return &organizationValidator{}
+}
+
+func (ov *organizationValidator) OrganizationMemberValidate(req entity.AddOrganizationMemberRequest) error {
+	return apperror.FromValidation(validation.ValidateStruct(&req,
+		validation.Field(
+			&req.Email,
+			validation.Required.Error("email is required"),
+			is.Email.Error("is not valid email format"),
+		),
+		validation.Field(
+			&req.Role,
+			validation.Required.Error("role is required"),
+			validation.In(entity.RoleAdmin, entity.RoleAccountant, entity.RoleViewer).Error("role must be admin, accountant or viewer"),
+		),
+	))
+}
+
+func (ov *organizationValidator) OrganizationRoleValidate(req entity.UpdateUserRoleRequest) error {
+	return apperror.FromValidation(validation.ValidateStruct(&req,
+		validation.Field(
+			&req.Role,
+			validation.Required.Error("role is required"),
+			validation.In(entity.RoleAdmin, entity.RoleAccountant, entity.RoleViewer).Error("role must be admin, accountant or viewer"),
+		),
+	))
+}
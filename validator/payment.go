@@ -0,0 +1,53 @@
+package validator
+
+import (
+	"next-learn-go/apperror"
+	"next-learn-go/entity"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+type PaymentValidator interface {
+	PaymentValidate(payment entity.Payment) error
+	RefundValidate(refund entity.Refund) error
+}
+
+type paymentValidator struct{}
+
+func NewPaymentValidator() PaymentValidator {
+	return &paymentValidator{}
+}
+
+func (pv *paymentValidator) PaymentValidate(payment entity.Payment) error {
+	return apperror.FromValidation(validation.ValidateStruct(&payment,
+		validation.Field(
+			&payment.Amount,
+			validation.Required.Error("amount is required"),
+			validation.Min(1).Error("amount must be positive"),
+		),
+		validation.Field(
+			&payment.Method,
+			validation.Required.Error("method is required"),
+			validation.In(entity.PaymentMethodBankTransfer, entity.PaymentMethodCard, entity.PaymentMethodCash, entity.PaymentMethodCheck, entity.PaymentMethodOther).
+				Error("method must be bank_transfer, card, cash, check or other"),
+		),
+		validation.Field(
+			&payment.Reference,
+			validation.RuneLength(0, 255).Error("limited max 255 char"),
+		),
+	))
+}
+
+func (pv *paymentValidator) RefundValidate(refund entity.Refund) error {
+	return apperror.FromValidation(validation.ValidateStruct(&refund,
+		validation.Field(
+			&refund.Amount,
+			validation.Required.Error("amount is required"),
+			validation.Min(1).Error("amount must be positive"),
+		),
+		validation.Field(
+			&refund.Reason,
+			validation.RuneLength(0, 255).Error("limited max 255 char"),
+		),
+	))
+}
@@ -0,0 +1,68 @@
+package validator
+
+import (
+	"errors"
+	"next-learn-go/apperror"
+	"next-learn-go/entity"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+type RecurringInvoiceValidator interface {
+	RecurringInvoiceValidate(recurringInvoice entity.RecurringInvoice) error
+}
+
+type recurringInvoiceValidator struct{}
+
+func NewRecurringInvoiceValidator() RecurringInvoiceValidator {
+	return &recurringInvoiceValidator{}
+}
+
+func (rv *recurringInvoiceValidator) RecurringInvoiceValidate(recurringInvoice entity.RecurringInvoice) error {
+	return apperror.FromValidation(validation.ValidateStruct(&recurringInvoice,
+		validation.Field(
+			&recurringInvoice.CustomerId,
+			validation.Required.Error("customer_id is required"),
+		),
+		validation.Field(
+			&recurringInvoice.Description,
+			validation.Required.Error("description is required"),
+			validation.RuneLength(1, 255).Error("limited max 255 char"),
+		),
+		validation.Field(
+			&recurringInvoice.Amount,
+			validation.Required.Error("amount is required"),
+			validation.Min(1).Error("amount must be positive"),
+		),
+		validation.Field(
+			&recurringInvoice.TaxRate,
+			validation.Min(0.0).Error("tax_rate must not be negative"),
+			validation.Max(100.0).Error("tax_rate must not exceed 100"),
+		),
+		validation.Field(
+			&recurringInvoice.Frequency,
+			validation.Required.Error("frequency is required"),
+			validation.In(entity.RecurrenceMonthly, entity.RecurrenceQuarterly, entity.RecurrenceYearly).
+				Error("frequency must be monthly, quarterly or yearly"),
+		),
+		validation.Field(
+			&recurringInvoice.DayOfMonth,
+			validation.Required.Error("day_of_month is required"),
+			validation.Min(1).Error("day_of_month must be between 1 and 31"),
+			validation.Max(31).Error("day_of_month must be between 1 and 31"),
+		),
+		validation.Field(
+			&recurringInvoice.StartDate,
+			validation.Required.Error("start_date is required"),
+		),
+		validation.Field(
+			&recurringInvoice.EndDate,
+			validation.By(func(value interface{}) error {
+				if !recurringInvoice.EndDate.IsZero() && recurringInvoice.EndDate.Time.Before(recurringInvoice.StartDate) {
+					return errors.New("end_date must not be before start_date")
+				}
+				return nil
+			}),
+		),
+	))
+}
@@ -0,0 +1,65 @@
+package validator
+
+import (
+	"errors"
+	"next-learn-go/apperror"
+	"next-learn-go/entity"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+type RevenueValidator interface {
+	RevenueQueryValidate(query entity.RevenueQuery) error
+}
+
+type revenueValidator struct{}
+
+func NewRevenueValidator() RevenueValidator {
+	return &revenueValidator{}
+}
+
+// 1 回の集計で返す期間数の上限
+const maxRevenuePeriods = 1000
+
+var revenuePeriodLength = map[string]time.Duration{
+	entity.GranularityDay:     24 * time.Hour,
+	entity.GranularityWeek:    7 * 24 * time.Hour,
+	entity.GranularityMonth:   28 * 24 * time.Hour,
+	entity.GranularityQuarter: 90 * 24 * time.Hour,
+	entity.GranularityYear:    365 * 24 * time.Hour,
+}
+
+func (rv *revenueValidator) RevenueQueryValidate(query entity.RevenueQuery) error {
+	return apperror.FromValidation(validation.ValidateStruct(&query,
+		validation.Field(
+			&query.Granularity,
+			validation.Required.Error("granularity is required"),
+			validation.In(
+				entity.GranularityDay,
+				entity.GranularityWeek,
+				entity.GranularityMonth,
+				entity.GranularityQuarter,
+				entity.GranularityYear,
+			).Error("granularity must be day, week, month, quarter or year"),
+		),
+		validation.Field(
+			&query.From,
+			validation.Required.Error("from is required"),
+		),
+		validation.Field(
+			&query.To,
+			validation.Required.Error("to is required"),
+			validation.By(func(value interface{}) error {
+				if query.To.Before(query.From) {
+					return errors.New("to must not be before from")
+				}
+				length, ok := revenuePeriodLength[query.Granularity]
+				if ok && query.To.Sub(query.From)/length > maxRevenuePeriods {
+					return errors.New("range is too long for this granularity")
+				}
+				return nil
+			}),
+		),
+	))
+}
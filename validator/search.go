@@ -0,0 +1,33 @@
+package validator
+
+import (
+	"next-learn-go/apperror"
+	"next-learn-go/entity"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+type SearchValidator interface {
+	SearchQueryValidate(query entity.SearchQuery) error
+}
+
+type searchValidator struct{}
+
+func NewSearchValidator() SearchValidator {
+	return &searchValidator{}
+}
+
+func (sv *searchValidator) SearchQueryValidate(query entity.SearchQuery) error {
+	return apperror.FromValidation(validation.ValidateStruct(&query,
+		validation.Field(
+			&query.Query,
+			validation.Required.Error("q is required"),
+			validation.RuneLength(1, 100).Error("q is limited to 100 characters"),
+		),
+		validation.Field(
+			&query.Limit,
+			validation.Min(1).Error("limit must be between 1 and 50"),
+			validation.Max(50).Error("limit must be between 1 and 50"),
+		),
+	))
+}
@@ -1,6 +1,7 @@
 package validator
 
 import (
+	"next-learn-go/apperror"
 	"next-learn-go/entity"
 
 	validation "github.com/go-ozzo/ozzo-validation/v4"
@@ -18,7 +19,7 @@ func NewUserValidator() UserValidator {
 }
 
 func (uv *userValidator) UserValidate(user entity.User) error {
-	return validation.ValidateStruct(&user,
+	return apperror.FromValidation(validation.ValidateStruct(&user,
 		validation.Field(
 			&user.Email,
 			validation.Required.Error("email is required"),
@@ -30,5 +31,5 @@ func (uv *userValidator) UserValidate(user entity.User) error {
 			validation.Required.Error("password is required"),
 			validation.RuneLength(6, 30).Error("limited min 6 max 30 char"),
 		),
-	)
+	))
 }